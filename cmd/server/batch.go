@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/stats"
+	"github.com/evgeniron/API-Validator/validate"
+)
+
+/*
+Batch validation streams both ways: endpoints are decoded from the request body as they arrive, validated by a
+bounded pool of workers, and the results are written back in the input order as soon as the next one in order
+is ready. At most batchWindow endpoints are in flight, so memory stays bounded whatever the batch size.
+*/
+
+const batchWindow = 64
+
+type batchResult struct {
+	Index  int                        `json:"index"`
+	Report *validate.ValidationReport `json:"report"`
+	Error  string                     `json:"error,omitempty"`
+}
+
+type batchJob struct {
+	index    int
+	endpoint *validate.Endpoint
+	result   chan batchResult
+}
+
+// ValidateBatch validates a JSON array or an NDJSON stream of endpoints. Results are
+// streamed as NDJSON when the request is NDJSON and as a JSON array otherwise.
+func (s *Server) ValidateBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// HTTP/1 servers discard the unread request body once the response starts,
+		// full duplex keeps it readable while results are streamed
+		_ = http.NewResponseController(w).EnableFullDuplex()
+
+		// the whole batch is validated against a single snapshot of the models
+		results := validateStream(s.db.Snapshot(), s.stats, validate.NewEndpointDecoder(r.Body), s.batchWorkers)
+		writeBatch(w, results, isNDJSON(r))
+	}
+}
+
+// validateStream validates the decoded endpoints with a pool of workers and returns
+// the results in the input order, the reports are recorded by recorder
+func validateStream(db model.Reader, recorder *stats.Recorder, decoder *validate.EndpointDecoder, workers int) <-chan batchResult {
+	jobs := make(chan batchJob)
+	pending := make(chan chan batchResult, batchWindow)
+	results := make(chan batchResult)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for job := range jobs {
+				result := batchResult{Index: job.index}
+				report, err := validateEndpoint(db, recorder, job.endpoint)
+				if err != nil {
+					result.Error = err.Error()
+				}
+				result.Report = report
+				job.result <- result
+			}
+		}()
+	}
+
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for index := 0; ; index++ {
+			endpoint, err := decoder.Decode()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			// blocks while the window is full
+			result := make(chan batchResult, 1)
+			pending <- result
+			if err != nil {
+				result <- batchResult{Index: index, Error: err.Error()}
+				return
+			}
+			jobs <- batchJob{index: index, endpoint: endpoint, result: result}
+		}
+	}()
+
+	go func() {
+		defer close(results)
+		for result := range pending {
+			results <- <-result
+		}
+	}()
+	return results
+}
+
+// writeBatch writes every result, flushing each one. Write errors are ignored so
+// that the results are always drained and the validation goroutines exit.
+func writeBatch(w http.ResponseWriter, results <-chan batchResult, ndjson bool) {
+	if ndjson {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	separator := "["
+	for result := range results {
+		if !ndjson {
+			_, _ = io.WriteString(w, separator)
+			separator = ","
+		}
+		_ = encoder.Encode(result)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if !ndjson {
+		if separator == "[" {
+			_, _ = io.WriteString(w, separator)
+		}
+		_, _ = io.WriteString(w, "]\n")
+	}
+}
+
+func isNDJSON(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "application/x-ndjson" || strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+}
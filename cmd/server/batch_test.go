@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	validEndpoint      = `{"path": "/users/info", "method": "GET", "headers": [{"name": "Authorization", "value": "Bearer 56ee9b7a-da8e-45a1-aade-a57761b912c4"}]}`
+	invalidEndpoint    = `{"path": "/users/info", "method": "GET", "headers": []}`
+	unmodelledEndpoint = `{"path": "/users/delete", "method": "POST"}`
+)
+
+func newBatchTestServer(t *testing.T) *Server {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	modelFile, err := os.Open("test_data/models.json")
+	require.NoError(t, err)
+	defer modelFile.Close()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, modelFile))
+	require.Equal(t, http.StatusOK, w.Code)
+	return srv
+}
+
+func TestValidateBatch(t *testing.T) {
+	srv := newBatchTestServer(t)
+
+	var endpoints []string
+	var expected []*bool
+	valid, invalid := true, false
+	for i := 0; i < 3*batchWindow; i++ {
+		switch i % 3 {
+		case 0:
+			endpoints, expected = append(endpoints, validEndpoint), append(expected, &valid)
+		case 1:
+			endpoints, expected = append(endpoints, invalidEndpoint), append(expected, &invalid)
+		default:
+			endpoints, expected = append(endpoints, unmodelledEndpoint), append(expected, nil)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+	}{
+		{name: "JSON array", body: "[" + strings.Join(endpoints, ",") + "]", contentType: "application/json"},
+		{name: "NDJSON", body: strings.Join(endpoints, "\n"), contentType: "application/x-ndjson"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, batchRoute, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, r)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+
+			var results []batchResult
+			if tt.contentType == "application/json" {
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&results))
+			} else {
+				decoder := json.NewDecoder(w.Body)
+				for decoder.More() {
+					var result batchResult
+					require.NoError(t, decoder.Decode(&result))
+					results = append(results, result)
+				}
+			}
+
+			require.Len(t, results, len(expected))
+			for i, result := range results {
+				require.Equal(t, i, result.Index)
+				require.Empty(t, result.Error)
+				if expected[i] == nil {
+					require.Nil(t, result.Report)
+					continue
+				}
+				require.Equal(t, *expected[i], result.Report.Valid)
+			}
+		})
+	}
+}
+
+func TestValidateBatchMalformed(t *testing.T) {
+	srv := newBatchTestServer(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{name: "Empty array", body: "[]", expected: "[]"},
+		{name: "Truncated array", body: "[" + validEndpoint + ", {\"path\": ", expected: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, batchRoute, strings.NewReader(tt.body)))
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Contains(t, w.Body.String(), tt.expected)
+
+			var results []batchResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+		})
+	}
+}
+
+// TestValidateBatchStreaming checks results are streamed back while the request is still being sent
+func TestValidateBatchStreaming(t *testing.T) {
+	server := httptest.NewServer(newBatchTestServer(t))
+	defer server.Close()
+
+	body, requestWriter := io.Pipe()
+	r, err := http.NewRequest(http.MethodPost, server.URL+batchRoute, body)
+	require.NoError(t, err)
+	r.Header.Set("Content-Type", "application/x-ndjson")
+
+	// unblocks the request when the test fails before closing the pipe
+	defer requestWriter.Close()
+
+	// the pipe blocks until the client sends the body, which starts with the request
+	go func() {
+		_, _ = io.WriteString(requestWriter, validEndpoint+"\n")
+	}()
+
+	resp, err := http.DefaultClient.Do(r)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	results := bufio.NewScanner(resp.Body)
+	for i, endpoint := range []string{invalidEndpoint, unmodelledEndpoint} {
+		require.True(t, results.Scan())
+
+		var result batchResult
+		require.NoError(t, json.Unmarshal(results.Bytes(), &result))
+		require.Equal(t, i, result.Index)
+
+		_, err = io.WriteString(requestWriter, endpoint+"\n")
+		require.NoError(t, err)
+	}
+	require.NoError(t, requestWriter.Close())
+
+	var remaining int
+	for results.Scan() {
+		remaining++
+	}
+	require.Equal(t, 1, remaining)
+}
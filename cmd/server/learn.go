@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/validate"
+)
+
+type learnResponse struct {
+	Observed int              `json:"observed"`
+	Models   []model.Endpoint `json:"models"`
+}
+
+// HandleLearn observes a JSON array or an NDJSON stream of captured endpoints and
+// responds with the candidate models learned so far
+func (s *Server) HandleLearn() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		decoder := validate.NewEndpointDecoder(r.Body)
+		for {
+			endpoint, err := decoder.Decode()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				respond(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.learner.Observe(endpoint)
+		}
+		respond(w, r, http.StatusOK, learnResponse{Observed: s.learner.Observed(), Models: s.learner.Models()})
+	}
+}
+
+func (s *Server) HandleListLearned() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respond(w, r, http.StatusOK, learnResponse{Observed: s.learner.Observed(), Models: s.learner.Models()})
+	}
+}
+
+func (s *Server) HandleResetLearned() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.learner.Reset()
+		respond(w, r, http.StatusOK, nil)
+	}
+}
+
+// HandlePromoteLearned stores the candidate models, each as a new revision so that
+// promoted models can be rolled back
+func (s *Server) HandlePromoteLearned() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		models := s.learner.Models()
+		problems := model.Lint(models, validate.KnownTypeIn(s.db.Snapshot()))
+		if !acceptLint(w, r, problems) {
+			return
+		}
+
+		if err := model.StoreModels(s.db, models, author(r)); err != nil {
+			respond(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respond(w, r, http.StatusOK, modelUploadResponse{Stored: len(models), Warnings: problems})
+	}
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 
+	"github.com/evgeniron/API-Validator/model"
 	"github.com/evgeniron/API-Validator/store"
 )
 
@@ -16,10 +19,50 @@ func main() {
 }
 
 func run() error {
-	db, err := store.NewInMemoryDB()
+	dbPath := flag.String("db", "", "path of the model store file, models are kept in memory only when empty")
+	upstream := flag.String("upstream", "", "URL of the upstream service, enables the proxy mode")
+	proxyAddr := flag.String("proxy-addr", ":8080", "address the proxy listens on")
+	proxyMode := flag.String("proxy-mode", string(ModeMonitor), "default proxy mode of the endpoints: enforce, monitor or off")
+	proxyConfig := flag.String("proxy-config", "", "path of the proxy config file, setting the mode per endpoint")
+	flag.Parse()
+
+	db, err := openDB(*dbPath)
 	if err != nil {
 		return err
 	}
 	srv := NewServer(db)
-	return http.ListenAndServe(":5000", srv)
+
+	if *upstream == "" {
+		return http.ListenAndServe(":5000", srv)
+	}
+
+	proxy, err := newProxy(srv, *upstream, *proxyMode, *proxyConfig)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- http.ListenAndServe(":5000", srv) }()
+	go func() { errs <- http.ListenAndServe(*proxyAddr, proxy) }()
+	return <-errs
+}
+
+func newProxy(srv *Server, upstream, mode, configPath string) (*Proxy, error) {
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil || upstreamURL.Scheme == "" || upstreamURL.Host == "" {
+		return nil, fmt.Errorf("invalid upstream URL %q", upstream)
+	}
+
+	config, err := LoadProxyConfig(configPath, ProxyMode(mode))
+	if err != nil {
+		return nil, err
+	}
+	return srv.NewProxy(upstreamURL, config)
+}
+
+func openDB(path string) (*store.Store, error) {
+	if path == "" {
+		return store.NewInMemoryDB()
+	}
+	return store.NewFileDB(path, model.RecordKinds)
 }
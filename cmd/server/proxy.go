@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/stats"
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/evgeniron/API-Validator/validate"
+)
+
+/*
+In proxy mode the validator sits in front of an upstream service. Every request is
+validated against its stored model and then forwarded or rejected, depending on
+the mode of its endpoint:
+
+	enforce  invalid requests are rejected with 400 and the validation report
+	monitor  invalid requests are forwarded, their reports only counted in the stats
+	off      requests are forwarded without validation
+
+Requests without a model are always forwarded.
+*/
+
+type ProxyMode string
+
+const (
+	ModeEnforce ProxyMode = "enforce"
+	ModeMonitor ProxyMode = "monitor"
+	ModeOff     ProxyMode = "off"
+)
+
+func (m ProxyMode) valid() bool {
+	return m == ModeEnforce || m == ModeMonitor || m == ModeOff
+}
+
+// ProxyConfig sets the mode of the endpoint models, Default applies to the models
+// without a mode of their own
+type ProxyConfig struct {
+	Default   ProxyMode      `json:"default"`
+	Endpoints []EndpointMode `json:"endpoints"`
+}
+
+// EndpointMode sets the mode of the model with the path and method, the path is
+// the model path, e.g. "/users/{id}"
+type EndpointMode struct {
+	Path   string    `json:"path"`
+	Method string    `json:"method"`
+	Mode   ProxyMode `json:"mode"`
+}
+
+// LoadProxyConfig reads the config file, the default mode of the file takes
+// precedence over defaultMode
+func LoadProxyConfig(path string, defaultMode ProxyMode) (ProxyConfig, error) {
+	config := ProxyConfig{Default: defaultMode}
+	if path == "" {
+		return config, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return config, fmt.Errorf("error opening proxy config: %w", err)
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(&config); err != nil {
+		return config, fmt.Errorf("error decoding proxy config: %w", err)
+	}
+	return config, nil
+}
+
+type Proxy struct {
+	db      *store.Store
+	stats   *stats.Recorder
+	forward *httputil.ReverseProxy
+
+	defaultMode ProxyMode
+	// modes are keyed by method and model path
+	modes map[string]ProxyMode
+}
+
+// NewProxy returns a proxy to upstream validating against the server models. The
+// proxy reports go to the server stats.
+func (s *Server) NewProxy(upstream *url.URL, config ProxyConfig) (*Proxy, error) {
+	if !config.Default.valid() {
+		return nil, fmt.Errorf("invalid default proxy mode %q", config.Default)
+	}
+
+	modes := make(map[string]ProxyMode, len(config.Endpoints))
+	for _, endpoint := range config.Endpoints {
+		if !endpoint.Mode.valid() {
+			return nil, fmt.Errorf("invalid proxy mode %q for %s %s", endpoint.Mode, endpoint.Method, endpoint.Path)
+		}
+		modes[strings.ToUpper(endpoint.Method)+" "+endpoint.Path] = endpoint.Mode
+	}
+
+	return &Proxy{
+		db:          s.db,
+		stats:       s.stats,
+		forward:     httputil.NewSingleHostReverseProxy(upstream),
+		defaultMode: config.Default,
+		modes:       modes,
+	}, nil
+}
+
+func (p *Proxy) mode(endpointModel *model.EndpointModel) ProxyMode {
+	if mode, ok := p.modes[endpointModel.Method+" "+endpointModel.Path]; ok {
+		return mode
+	}
+	return p.defaultMode
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the request is judged on a single snapshot of the models
+	db := p.db.Snapshot()
+	endpointModel, err := model.GetModel(db, r.URL.Path, r.Method)
+	if err != nil {
+		// unmodelled requests are forwarded, we can add metrics/logs here for store errors
+		p.forward.ServeHTTP(w, r)
+		return
+	}
+
+	mode := p.mode(endpointModel)
+	if mode == ModeOff {
+		p.forward.ServeHTTP(w, r)
+		return
+	}
+
+	report, err := validate.ValidateRequest(r, endpointModel, db)
+	if err != nil {
+		if mode == ModeEnforce {
+			status := http.StatusBadRequest
+			if errors.Is(err, validate.ErrBodyTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			w.Header().Set("Content-Type", "application/json")
+			respond(w, r, status, err.Error())
+			return
+		}
+		p.forward.ServeHTTP(w, r)
+		return
+	}
+
+	p.stats.Record(endpointModel.Path, endpointModel.Method, report)
+	if !report.Valid && mode == ModeEnforce {
+		w.Header().Set("Content-Type", "application/json")
+		respond(w, r, http.StatusBadRequest, report)
+		return
+	}
+	p.forward.ServeHTTP(w, r)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/evgeniron/API-Validator/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxy(t *testing.T) {
+	var forwarded []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		forwarded = append(forwarded, r.Method+" "+r.URL.Path+" "+string(body))
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	w := httptest.NewRecorder()
+	models := `[
+		{"path": "/users/{id}", "method": "PUT", "path_params": [{"name": "id", "types": ["Int"], "required": true}],
+			"body": [{"name": "age", "types": ["Int"], "required": true}]},
+		{"path": "/users/{id}", "method": "GET", "path_params": [{"name": "id", "types": ["Int"], "required": true}]},
+		{"path": "/health", "method": "GET", "query_params": [{"name": "deep", "types": ["String"], "required": true}]}]`
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(models)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	config, err := LoadProxyConfig("test_data/proxy.json", ModeEnforce)
+	require.NoError(t, err)
+	proxy, err := srv.NewProxy(upstreamURL, config)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		body      string
+		status    int
+		forwarded bool
+	}{
+		{name: "Enforced valid", method: http.MethodPut, target: "/users/1", body: `{"age": 42}`, status: http.StatusTeapot, forwarded: true},
+		{name: "Enforced invalid", method: http.MethodPut, target: "/users/1", body: `{"age": "old"}`, status: http.StatusBadRequest},
+		{name: "Enforced malformed body", method: http.MethodPut, target: "/users/1", body: `{"age": `, status: http.StatusBadRequest},
+		{name: "Monitored invalid", method: http.MethodGet, target: "/users/abc", status: http.StatusTeapot, forwarded: true},
+		{name: "Off", method: http.MethodGet, target: "/health", status: http.StatusTeapot, forwarded: true},
+		{name: "Unmodelled", method: http.MethodPost, target: "/orders", body: `{"id": 1}`, status: http.StatusTeapot, forwarded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forwarded = nil
+			w := httptest.NewRecorder()
+			proxy.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.forwarded {
+				assert.Equal(t, []string{tt.method + " " + tt.target + " " + tt.body}, forwarded)
+			} else {
+				assert.Empty(t, forwarded)
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	endpointStats := srv.stats.Stats("/users", "")
+	require.Len(t, endpointStats, 2)
+	// the malformed request has no report to count
+	assert.Equal(t, "GET", endpointStats[0].Method)
+	assert.Equal(t, 1, endpointStats[0].Windows[0].Invalid)
+	assert.Equal(t, "PUT", endpointStats[1].Method)
+	assert.Equal(t, 2, endpointStats[1].Windows[0].Requests)
+	assert.Equal(t, 1, endpointStats[1].Windows[0].Invalid)
+	assert.Empty(t, srv.stats.Stats("/health", ""))
+}
+
+func TestProxyBodyTooLarge(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer upstream.Close()
+	upstreamURL, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(`[{"path": "/upload", "method": "POST"}]`)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	large := `{"data": "` + strings.Repeat("x", validate.MaxBodySize) + `"}`
+	for mode, status := range map[ProxyMode]int{ModeEnforce: http.StatusRequestEntityTooLarge, ModeMonitor: http.StatusOK} {
+		proxy, err := srv.NewProxy(upstreamURL, ProxyConfig{Default: mode})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		proxy.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(large)))
+		require.Equal(t, status, w.Code, mode)
+		if status == http.StatusOK {
+			assert.Equal(t, large, w.Body.String())
+		}
+	}
+}
+
+func TestProxyConfig(t *testing.T) {
+	config, err := LoadProxyConfig("", ModeMonitor)
+	require.NoError(t, err)
+	assert.Equal(t, ProxyConfig{Default: ModeMonitor}, config)
+
+	config, err = LoadProxyConfig("test_data/proxy.json", ModeEnforce)
+	require.NoError(t, err)
+	assert.Equal(t, ModeMonitor, config.Default)
+	assert.Len(t, config.Endpoints, 2)
+
+	_, err = LoadProxyConfig("test_data/missing.json", ModeMonitor)
+	assert.Error(t, err)
+
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	_, err = newProxy(srv, "localhost:8000", "monitor", "")
+	assert.Error(t, err)
+	_, err = newProxy(srv, "http://localhost:8000", "block", "")
+	assert.Error(t, err)
+	_, err = srv.NewProxy(&url.URL{Scheme: "http", Host: "localhost"}, ProxyConfig{Default: ModeOff, Endpoints: []EndpointMode{{Path: "/", Method: "GET", Mode: "block"}}})
+	assert.Error(t, err)
+	_, err = newProxy(srv, "http://localhost:8000", "enforce", "test_data/proxy.json")
+	assert.NoError(t, err)
+}
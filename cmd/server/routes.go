@@ -5,11 +5,42 @@ import (
 )
 
 const (
-	modelRoute      = "/v1/model"
-	validationRoute = "/v1/validate"
+	modelRoute         = "/v1/model"
+	openAPIModelRoute  = "/v1/model/openapi"
+	endpointModelRoute = "/v1/model/{method}/{path:.*}"
+	historyRoute       = "/v1/history/{method}/{path:.*}"
+	rollbackRoute      = "/v1/rollback/{method}/{path:.*}"
+	validationRoute    = "/v1/validate"
+	batchRoute         = "/v1/validate/batch"
+	responseRoute      = "/v1/validate/response"
+	rawRoute           = "/v1/validate/raw"
+	typesRoute         = "/v1/types"
+	learnRoute         = "/v1/learn"
+	promoteRoute       = "/v1/learn/promote"
+	statsRoute         = "/v1/stats"
 )
 
 func (s *Server) Routes() {
 	s.router.HandleFunc(modelRoute, s.HandleModel()).Methods(http.MethodPut)
+	s.router.HandleFunc(modelRoute, s.HandleListModels()).Methods(http.MethodGet)
+	s.router.HandleFunc(openAPIModelRoute, s.HandleOpenAPIImport()).Methods(http.MethodPut)
+	s.router.HandleFunc(openAPIModelRoute, s.HandleOpenAPIExport()).Methods(http.MethodGet)
+	s.router.HandleFunc(endpointModelRoute, s.HandleGetModel()).Methods(http.MethodGet)
+	s.router.HandleFunc(endpointModelRoute, s.HandleDeleteModel()).Methods(http.MethodDelete)
+	s.router.HandleFunc(endpointModelRoute, s.HandlePatchModel()).Methods(http.MethodPatch)
+	s.router.HandleFunc(historyRoute, s.HandleModelDiff()).Methods(http.MethodGet).Queries("from", "{from}", "to", "{to}")
+	s.router.HandleFunc(historyRoute, s.HandleModelHistory()).Methods(http.MethodGet)
+	s.router.HandleFunc(rollbackRoute, s.HandleRollback()).Methods(http.MethodPost)
 	s.router.HandleFunc(validationRoute, s.ValidateEndpoint()).Methods(http.MethodPost)
+	s.router.HandleFunc(batchRoute, s.ValidateBatch()).Methods(http.MethodPost)
+	s.router.HandleFunc(responseRoute, s.ValidateResponse()).Methods(http.MethodPost)
+	s.router.HandleFunc(rawRoute, s.ValidateRaw()).Methods(http.MethodPost)
+	s.router.HandleFunc(typesRoute, s.HandleTypes()).Methods(http.MethodPut)
+	s.router.HandleFunc(typesRoute, s.HandleListTypes()).Methods(http.MethodGet)
+	s.router.HandleFunc(learnRoute, s.HandleLearn()).Methods(http.MethodPost)
+	s.router.HandleFunc(learnRoute, s.HandleListLearned()).Methods(http.MethodGet)
+	s.router.HandleFunc(learnRoute, s.HandleResetLearned()).Methods(http.MethodDelete)
+	s.router.HandleFunc(promoteRoute, s.HandlePromoteLearned()).Methods(http.MethodPost)
+	s.router.HandleFunc(statsRoute, s.HandleStats()).Methods(http.MethodGet)
+	s.router.HandleFunc(statsRoute, s.HandleResetStats()).Methods(http.MethodDelete)
 }
@@ -3,10 +3,19 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
 
+	"github.com/evgeniron/API-Validator/learn"
 	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/openapi"
+	"github.com/evgeniron/API-Validator/stats"
 	"github.com/evgeniron/API-Validator/store"
+	"github.com/evgeniron/API-Validator/utils"
 	"github.com/evgeniron/API-Validator/validate"
 	"github.com/gorilla/mux"
 )
@@ -14,11 +23,23 @@ import (
 type Server struct {
 	router *mux.Router
 	db     *store.Store
+
+	// batchWorkers bounds the number of endpoints of a batch validated concurrently
+	batchWorkers int
+
+	// learner infers candidate models from the captures posted to the learn route
+	learner *learn.Learner
+
+	// stats counts the validation reports
+	stats *stats.Recorder
 }
 
 func NewServer(db *store.Store) *Server {
 	s := &Server{}
 	s.db = db
+	s.batchWorkers = runtime.GOMAXPROCS(0)
+	s.learner = learn.NewLearner(learn.DefaultOptions())
+	s.stats = stats.NewRecorder(stats.DefaultOptions())
 	s.router = mux.NewRouter()
 	s.Routes()
 
@@ -36,50 +57,446 @@ func (s *Server) ValidateEndpoint() http.HandlerFunc {
 		endpoint, err := validate.ParseEndpoint(r)
 		if err != nil {
 			respond(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// Load model from a single snapshot, so concurrent model uploads can't be observed half way
+		report, err := validateEndpoint(s.db.Snapshot(), s.stats, endpoint)
+		if err != nil {
+			respond(w, r, http.StatusInternalServerError, nil)
+			return
+		}
+
+		// Write response
+		respond(w, r, http.StatusOK, report)
+	}
+}
+
+// validateEndpoint validates the endpoint against its model and records the report.
+// The report is nil when the endpoint has no model or can't be validated.
+func validateEndpoint(db model.Reader, recorder *stats.Recorder, endpoint *validate.Endpoint) (*validate.ValidationReport, error) {
+	// Load model
+	model, err := model.GetModel(db, endpoint.Path, endpoint.Method)
+	if err != nil {
+		var e *store.RecordNotFoundError
+		if errors.As(err, &e) {
+			// we can add metrics/logs here for records not found
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Validate endpoint
+	report, err := validate.ValidateReportWithTypes(endpoint, model, db)
+	if err != nil {
+		// we can add metrics/logs/traces here for errors validating record
+		return nil, nil
+	}
+
+	recorder.Record(model.Path, model.Method, report)
+	return report, nil
+}
+
+// ValidateRaw validates a request as sent on the wire, or a HAR entry when the
+// content type is JSON
+func (s *Server) ValidateRaw() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request *http.Request
+		var err error
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+			var entry *validate.HAREntry
+			entry, err = validate.DecodeHAREntry(r.Body)
+			if err == nil {
+				request, err = entry.HTTPRequest()
+			}
+		} else {
+			request, err = validate.ReadRawRequest(r.Body)
+		}
+		if err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
 		}
 
-		// Load model
-		model, err := model.GetModel(s.db, endpoint.Path, endpoint.Method)
+		db := s.db.Snapshot()
+		endpointModel, err := model.GetModel(db, request.URL.Path, request.Method)
 		if err != nil {
 			var e *store.RecordNotFoundError
 			if errors.As(err, &e) {
-				// we can add metrics/logs here for records not found
 				respond(w, r, http.StatusOK, nil)
 				return
 			}
 			respond(w, r, http.StatusInternalServerError, nil)
+			return
+		}
+
+		report, err := validate.ValidateRequest(request, endpointModel, db)
+		if err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.stats.Record(endpointModel.Path, endpointModel.Method, report)
+		respond(w, r, http.StatusOK, report)
+	}
+}
+
+func (s *Server) ValidateResponse() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, err := validate.ParseResponse(r)
+		if err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
 		}
 
-		// Validate endpoint
-		report, err := validate.ValidateReport(endpoint, model)
+		db := s.db.Snapshot()
+		endpointModel, err := model.GetModel(db, response.Path, response.Method)
 		if err != nil {
-			// we can add metrics/logs/traces here for errors validating record
+			var e *store.RecordNotFoundError
+			if errors.As(err, &e) {
+				respond(w, r, http.StatusOK, nil)
+				return
+			}
+			respond(w, r, http.StatusInternalServerError, nil)
+			return
+		}
+		if len(endpointModel.Responses) == 0 {
+			// the responses of the endpoint are not modelled
 			respond(w, r, http.StatusOK, nil)
 			return
 		}
 
-		// Write response
+		report, err := validate.ValidateResponse(response, endpointModel, db)
+		if err != nil {
+			respond(w, r, http.StatusInternalServerError, nil)
+			return
+		}
 		respond(w, r, http.StatusOK, report)
 	}
 }
 
 func (s *Server) HandleModel() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		models, err := model.Decode(r)
+		file, err := model.DecodeModelFile(r.Body)
+		if err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		defined, err := compileTypes(file.Types)
 		if err != nil {
 			respond(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		err = model.StoreModels(s.db, models)
+		problems := model.Lint(file.Models, knownType(s.db.Snapshot(), defined))
+		problems = append(problems, validate.LintRules(file.Models)...)
+		if !acceptLint(w, r, problems) {
+			return
+		}
+
+		err = model.StoreModelFile(s.db, file, author(r))
 		if err != nil {
 			respond(w, r, http.StatusBadRequest, err.Error())
 			return
 		}
+		respond(w, r, http.StatusOK, modelUploadResponse{Stored: len(file.Models), Warnings: problems})
+	}
+}
+
+// HandleTypes stores type definitions, replacing the definitions with the same names
+func (s *Server) HandleTypes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var definitions []model.TypeDefinition
+		if err := utils.Decode(r, &definitions); err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if _, err := compileTypes(definitions); err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := model.StoreTypes(s.db, definitions); err != nil {
+			respond(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
 		respond(w, r, http.StatusOK, nil)
 	}
 }
 
+func (s *Server) HandleListTypes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		definitions, err := model.GetTypes(s.db)
+		if err != nil {
+			respond(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respond(w, r, http.StatusOK, definitions)
+	}
+}
+
+// compileTypes checks that every type definition compiles and returns the defined type names
+func compileTypes(definitions []model.TypeDefinition) (map[string]bool, error) {
+	defined := make(map[string]bool, len(definitions))
+	for i := range definitions {
+		if defined[definitions[i].Name] {
+			return nil, fmt.Errorf("type %q is defined more than once", definitions[i].Name)
+		}
+		if _, err := validate.CompileType(&definitions[i]); err != nil {
+			return nil, err
+		}
+		defined[definitions[i].Name] = true
+	}
+	return defined, nil
+}
+
+// knownType reports the types known to db or defined in the same upload
+func knownType(db model.Reader, defined map[string]bool) func(name string) bool {
+	known := validate.KnownTypeIn(db)
+	return func(name string) bool {
+		return defined[name] || known(name)
+	}
+}
+
+type modelUploadResponse struct {
+	Stored   int             `json:"stored"`
+	Warnings []model.Problem `json:"warnings"`
+}
+
+type lintErrorResponse struct {
+	Error    string          `json:"error"`
+	Problems []model.Problem `json:"problems"`
+}
+
+// acceptLint rejects uploaded models with lint problems, unless the "lenient" query
+// param is set and the problems are returned as warnings instead
+func acceptLint(w http.ResponseWriter, r *http.Request, problems []model.Problem) bool {
+	if len(problems) == 0 {
+		return true
+	}
+	if lenient, _ := strconv.ParseBool(r.URL.Query().Get("lenient")); lenient {
+		return true
+	}
+
+	respond(w, r, http.StatusBadRequest, lintErrorResponse{Error: "invalid models", Problems: problems})
+	return false
+}
+
+// HandleListModels lists the stored models, optionally filtered by the "path_prefix"
+// and "method" query params
+func (s *Server) HandleListModels() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := model.Filter{
+			PathPrefix: r.URL.Query().Get("path_prefix"),
+			Method:     r.URL.Query().Get("method"),
+		}
+
+		models, err := model.ListModels(s.db, filter)
+		if err != nil {
+			respond(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respond(w, r, http.StatusOK, models)
+	}
+}
+
+// HandleStats lists the validation counts of the endpoint models, optionally filtered
+// by the "path_prefix" and "method" query params like the models list
+func (s *Server) HandleStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		respond(w, r, http.StatusOK, s.stats.Stats(r.URL.Query().Get("path_prefix"), r.URL.Query().Get("method")))
+	}
+}
+
+func (s *Server) HandleResetStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.stats.Reset()
+		respond(w, r, http.StatusOK, nil)
+	}
+}
+
+// endpointModelKey extracts the stored model path and method from the route variables
+func endpointModelKey(r *http.Request) (string, string) {
+	vars := mux.Vars(r)
+	return "/" + vars["path"], strings.ToUpper(vars["method"])
+}
+
+func (s *Server) HandleGetModel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path, method := endpointModelKey(r)
+		endpointModel, err := model.GetExactModel(s.db, path, method)
+		if err != nil {
+			respondStoreError(w, r, err)
+			return
+		}
+		respond(w, r, http.StatusOK, endpointModel)
+	}
+}
+
+func (s *Server) HandleDeleteModel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path, method := endpointModelKey(r)
+		if err := model.DeleteModel(s.db, path, method); err != nil {
+			respondStoreError(w, r, err)
+			return
+		}
+		respond(w, r, http.StatusOK, nil)
+	}
+}
+
+func (s *Server) HandlePatchModel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var patches []model.FieldPatch
+		if err := utils.Decode(r, &patches); err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !acceptLint(w, r, model.LintPatches(patches, validate.KnownTypeIn(s.db.Snapshot()))) {
+			return
+		}
+
+		path, method := endpointModelKey(r)
+		endpointModel, err := model.PatchModel(s.db, path, method, patches, author(r))
+		if err != nil {
+			var e *model.PatchError
+			if errors.As(err, &e) {
+				respond(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+			respondStoreError(w, r, err)
+			return
+		}
+		respond(w, r, http.StatusOK, endpointModel)
+	}
+}
+
+func (s *Server) HandleModelHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path, method := endpointModelKey(r)
+		history, err := model.GetHistory(s.db, path, method)
+		if err != nil {
+			respondStoreError(w, r, err)
+			return
+		}
+		respond(w, r, http.StatusOK, history.Revisions)
+	}
+}
+
+// HandleModelDiff lists the changes between the "from" and "to" revisions
+func (s *Server) HandleModelDiff() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, fromErr := strconv.Atoi(mux.Vars(r)["from"])
+		to, toErr := strconv.Atoi(mux.Vars(r)["to"])
+		if fromErr != nil || toErr != nil {
+			respond(w, r, http.StatusBadRequest, "invalid revision numbers")
+			return
+		}
+
+		path, method := endpointModelKey(r)
+		changes, err := model.Diff(s.db, path, method, from, to)
+		if err != nil {
+			respondStoreError(w, r, err)
+			return
+		}
+		respond(w, r, http.StatusOK, changes)
+	}
+}
+
+type rollbackRequest struct {
+	Revision int `json:"revision"`
+}
+
+func (s *Server) HandleRollback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request rollbackRequest
+		if err := utils.Decode(r, &request); err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		path, method := endpointModelKey(r)
+		revision, err := model.Rollback(s.db, path, method, request.Revision, author(r))
+		if err != nil {
+			respondStoreError(w, r, err)
+			return
+		}
+		respond(w, r, http.StatusOK, revision)
+	}
+}
+
+// author identifies who changes the models, for the model history
+func author(r *http.Request) string {
+	return r.Header.Get("X-Author")
+}
+
+// respondStoreError responds with 404 for missing records and 500 otherwise
+func respondStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	var e *store.RecordNotFoundError
+	var revisionErr *model.RevisionNotFoundError
+	if errors.As(err, &e) || errors.As(err, &revisionErr) {
+		respond(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	respond(w, r, http.StatusInternalServerError, err.Error())
+}
+
+type openAPIImportResponse struct {
+	Imported int               `json:"imported"`
+	Warnings []openapi.Warning `json:"warnings"`
+}
+
+func (s *Server) HandleOpenAPIImport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := openapi.Parse(r.Body)
+		if err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		models, warnings := openapi.Import(doc)
+		problems := model.Lint(models, validate.KnownTypeIn(s.db.Snapshot()))
+		if !acceptLint(w, r, problems) {
+			return
+		}
+		for _, problem := range problems {
+			warnings = append(warnings, openapi.Warning{Location: problem.Location, Message: problem.Message})
+		}
+
+		err = model.StoreModels(s.db, models, author(r))
+		if err != nil {
+			respond(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		respond(w, r, http.StatusOK, openAPIImportResponse{Imported: len(models), Warnings: warnings})
+	}
+}
+
+// HandleOpenAPIExport renders the stored models as an OpenAPI document, in YAML
+// when the Accept header asks for it and in JSON otherwise
+func (s *Server) HandleOpenAPIExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		models, err := model.GetModels(s.db)
+		if err != nil {
+			respond(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		doc := openapi.Export(models)
+		if strings.Contains(r.Header.Get("Accept"), "yaml") {
+			w.Header().Set("Content-Type", "application/yaml")
+			w.WriteHeader(http.StatusOK)
+			// the status is already written, we can add logs here for encoding errors
+			_ = doc.EncodeYAML(w)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		respond(w, r, http.StatusOK, doc)
+	}
+}
+
 func respond(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
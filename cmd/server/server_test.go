@@ -2,13 +2,18 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/openapi"
+	"github.com/evgeniron/API-Validator/stats"
 	"github.com/evgeniron/API-Validator/store"
 	"github.com/evgeniron/API-Validator/validate"
 	"github.com/stretchr/testify/assert"
@@ -72,6 +77,751 @@ func TestServer(t *testing.T) {
 	}
 }
 
+func TestOpenAPIImport(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	document := `
+openapi: 3.0.0
+info:
+  title: users
+  version: "1"
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+            format: uuid
+        - name: session
+          in: cookie
+          schema:
+            type: string
+`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, openAPIModelRoute, strings.NewReader(document))
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result openAPIImportResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	require.Equal(t, 1, result.Imported)
+	require.Len(t, result.Warnings, 1)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, validationRoute, strings.NewReader(`{"path": "/users/42", "method": "GET"}`))
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var report validate.ValidationReport
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	require.False(t, report.Valid)
+	require.Len(t, report.PathParams, 1)
+	require.Equal(t, "UUID", report.PathParams[0].ExpectedType)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, openAPIModelRoute, strings.NewReader(`{"swagger": "2.0"}`))
+	srv.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestOpenAPIExport(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	modelFile, err := os.Open("test_data/models.json")
+	require.NoError(t, err)
+	defer modelFile.Close()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, modelFile))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+	}{
+		{name: "JSON", accept: "application/json", contentType: "application/json"},
+		{name: "Default", contentType: "application/json"},
+		{name: "YAML", accept: "application/yaml", contentType: "application/yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, openAPIModelRoute, nil)
+			r.Header.Set("Accept", tt.accept)
+			srv.ServeHTTP(w, r)
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+
+			doc, err := openapi.Parse(w.Body)
+			require.NoError(t, err)
+			require.Len(t, doc.Paths, 5)
+			require.NotNil(t, doc.Paths["/users/create"].Post)
+		})
+	}
+}
+
+func TestModelCRUD(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	modelFile, err := os.Open("test_data/models.json")
+	require.NoError(t, err)
+	defer modelFile.Close()
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, modelFile))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{name: "List", method: http.MethodGet, target: "/v1/model?path_prefix=/users&method=GET", status: http.StatusOK},
+		{name: "Get", method: http.MethodGet, target: "/v1/model/GET/users/info", status: http.StatusOK},
+		{name: "Get missing", method: http.MethodGet, target: "/v1/model/GET/users/missing", status: http.StatusNotFound},
+		{
+			name:   "Patch",
+			method: http.MethodPatch,
+			target: "/v1/model/get/users/info",
+			body:   `[{"op": "remove", "section": "headers", "name": "Authorization"}]`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Invalid patch",
+			method: http.MethodPatch,
+			target: "/v1/model/GET/users/info",
+			body:   `[{"op": "remove", "section": "headers", "name": "Authorization"}]`,
+			status: http.StatusBadRequest,
+		},
+		{name: "Delete", method: http.MethodDelete, target: "/v1/model/GET/users/info", status: http.StatusOK},
+		{name: "Delete missing", method: http.MethodDelete, target: "/v1/model/GET/users/info", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			require.Equal(t, tt.status, w.Code)
+		})
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/model?path_prefix=/users", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var models []model.EndpointModel
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&models))
+	require.Len(t, models, 1)
+	require.Equal(t, "/users/create", models[0].Path)
+}
+
+func TestModelLint(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	models := `[{"path": "/users/info", "method": "GET",
+		"headers": [{"name": "Authorization", "types": ["String", "Auth-Token"], "required": true}]}]`
+	problems := []model.Problem{{Location: "GET /users/info headers/Authorization", Message: `unknown type "Auth-Token"`}}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(models)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var rejected lintErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&rejected))
+	require.Equal(t, problems, rejected.Problems)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/model/GET/users/info", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute+"?lenient=true", strings.NewReader(models)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var accepted modelUploadResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&accepted))
+	require.Equal(t, modelUploadResponse{Stored: 1, Warnings: problems}, accepted)
+
+	w = httptest.NewRecorder()
+	patch := `[{"op": "add", "section": "body", "field": {"name": "age", "types": ["Integer"]}}]`
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/v1/model/GET/users/info", strings.NewReader(patch)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRules(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	w := httptest.NewRecorder()
+	invalid := `[{"path": "/bookings", "method": "POST", "rules": [{"name": "dates", "expr": "body.end_date >"}]}]`
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(invalid)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	models := `[{"path": "/bookings", "method": "POST",
+		"body": [{"name": "start_date", "types": ["Date"]}, {"name": "end_date", "types": ["Date"]}],
+		"rules": [{"name": "dates", "expr": "date(body.end_date) > date(body.start_date)", "message": "end_date must be after start_date"}]}]`
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(models)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []validate.ValidationError
+	}{
+		{
+			name: "Rule holds",
+			body: `[{"name": "start_date", "value": "01-02-2023"}, {"name": "end_date", "value": "02-02-2023"}]`,
+		},
+		{
+			name: "Rule fails",
+			body: `[{"name": "start_date", "value": "01-02-2023"}, {"name": "end_date", "value": "01-01-2023"}]`,
+			expected: []validate.ValidationError{{
+				FieldName:  "dates",
+				Path:       "/dates",
+				ErrorType:  validate.ErrRuleFailed,
+				ErrorValue: "end_date must be after start_date",
+				Constraint: "date(body.end_date) > date(body.start_date)",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			endpoint := `{"path": "/bookings", "method": "POST", "body": ` + tt.body + `}`
+			srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, validationRoute, strings.NewReader(endpoint)))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var report validate.ValidationReport
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+			require.Equal(t, tt.expected, report.Rules)
+			require.Equal(t, len(tt.expected) == 0, report.Valid)
+		})
+	}
+}
+
+func TestValidateRaw(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	w := httptest.NewRecorder()
+	models := `[{"path": "/users/{id}", "method": "PUT", "path_params": [{"name": "id", "types": ["Int"], "required": true}],
+		"query_params": [{"name": "notify", "types": ["String"]}],
+		"headers": [{"name": "Authorization", "types": ["BearerAuth"], "required": true}],
+		"body": [{"name": "age", "types": ["Int"], "required": true}]}]`
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(models)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	tests := []struct {
+		name        string
+		contentType string
+		request     string
+		status      int
+		valid       bool
+	}{
+		{
+			name:    "Raw JSON request",
+			request: "PUT /users/1?notify=yes HTTP/1.1\r\nHost: example.com\r\nAuthorization: Bearer abc\r\nContent-Type: application/json\r\n\r\n{\"age\": 42}",
+			status:  http.StatusOK,
+			valid:   true,
+		},
+		{
+			name:    "Raw form request",
+			request: "PUT /users/1 HTTP/1.1\r\nHost: example.com\r\nAuthorization: Bearer abc\r\nContent-Type: application/x-www-form-urlencoded\r\n\r\nage=42",
+			status:  http.StatusOK,
+			valid:   true,
+		},
+		{
+			name:    "Raw invalid request",
+			request: "PUT /users/abc HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\n\r\n{\"age\": \"old\"}",
+			status:  http.StatusOK,
+		},
+		{
+			name:        "HAR entry",
+			contentType: "application/json",
+			request: `{"request": {"method": "PUT", "url": "https://example.com/users/1",
+				"headers": [{"name": "authorization", "value": "Bearer abc"}, {"name": "user-agent", "value": "curl"}],
+				"postData": {"mimeType": "application/json", "text": "{\"age\": 42}"}}}`,
+			status: http.StatusOK,
+			valid:  true,
+		},
+		{name: "Malformed raw request", request: "hello", status: http.StatusBadRequest},
+		{name: "Malformed body", request: "PUT /users/1 HTTP/1.1\r\nHost: example.com\r\n\r\n{\"age\":", status: http.StatusBadRequest},
+		{name: "Malformed HAR entry", contentType: "application/json", request: `{"request": []}`, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, rawRoute, strings.NewReader(tt.request))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			srv.ServeHTTP(w, r)
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var report validate.ValidationReport
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+			require.Equal(t, tt.valid, report.Valid, "%+v", report)
+		})
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, rawRoute, strings.NewReader("GET /orders HTTP/1.1\r\nHost: example.com\r\n\r\n")))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `null`, w.Body.String())
+}
+
+func TestValidateResponse(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	w := httptest.NewRecorder()
+	models := `[{"path": "/users/{id}", "method": "GET", "path_params": [{"name": "id", "types": ["Int"], "required": true}],
+		"responses": {"200": {"headers": [{"name": "Content-Type", "types": ["String"], "required": true}],
+			"body": [{"name": "id", "types": ["Int"], "required": true}, {"name": "name", "types": ["String"]}]}}},
+		{"path": "/health", "method": "GET"}]`
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(models)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	tests := []struct {
+		name     string
+		response string
+		status   int
+		expected string
+	}{
+		{
+			name: "Valid response",
+			response: `{"path": "/users/1", "method": "GET", "status": 200,
+				"headers": [{"name": "content-type", "value": "application/json"}, {"name": "Date", "value": "today"}],
+				"body": [{"name": "id", "value": 1}, {"name": "name", "value": "bob"}]}`,
+			status:   http.StatusOK,
+			expected: `{"Path": "/users/1", "Method": "GET", "Status": 200, "StatusErrors": null, "Headers": null, "Body": null, "Valid": true}`,
+		},
+		{
+			name:     "Invalid response",
+			response: `{"path": "/users/1", "method": "GET", "status": 200, "body": [{"name": "id", "value": "1"}]}`,
+			status:   http.StatusOK,
+			expected: `{"Path": "/users/1", "Method": "GET", "Status": 200, "StatusErrors": null,
+				"Headers": [{"FieldName": "Content-Type", "Path": "/Content-Type", "ErrorType": "missing required field", "ExpectedType": "", "ErrorValue": null}],
+				"Body": [{"FieldName": "id", "Path": "/id", "ErrorType": "value type mismatch", "ExpectedType": "Int", "ErrorValue": "1"}],
+				"Valid": false}`,
+		},
+		{
+			name:     "Unexpected status",
+			response: `{"path": "/users/1", "method": "GET", "status": 500}`,
+			status:   http.StatusOK,
+			expected: `{"Path": "/users/1", "Method": "GET", "Status": 500,
+				"StatusErrors": [{"FieldName": "status", "Path": "/status", "ErrorType": "unexpected status code", "ExpectedType": "", "ErrorValue": 500, "Constraint": [200]}],
+				"Headers": null, "Body": null, "Valid": false}`,
+		},
+		{
+			name:     "Responses not modelled",
+			response: `{"path": "/health", "method": "GET", "status": 200}`,
+			status:   http.StatusOK,
+			expected: `null`,
+		},
+		{
+			name:     "Unmodelled endpoint",
+			response: `{"path": "/orders", "method": "GET", "status": 200}`,
+			status:   http.StatusOK,
+			expected: `null`,
+		},
+		{
+			name:     "Malformed",
+			response: `{"path": "/users/1", "status": "ok"}`,
+			status:   http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, responseRoute, strings.NewReader(tt.response)))
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+			if tt.expected != "" {
+				require.JSONEq(t, tt.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLearn(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	captures := `{"path": "/users/42", "method": "GET", "query_params": [{"name": "verbose", "value": "1"}], "headers": [{"name": "Authorization", "value": "Bearer abc"}]}
+{"path": "/users/43", "method": "GET", "headers": [{"name": "Authorization", "value": "Bearer def"}]}`
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, learnRoute, strings.NewReader(captures)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	expected := learnResponse{Observed: 2, Models: []model.Endpoint{{
+		Path:        "/users/{user_id}",
+		Method:      "GET",
+		PathParams:  []model.Field{{Name: "user_id", Types: []string{"Int"}, Required: true}},
+		QueryParams: []model.Field{{Name: "verbose", Types: []string{"Int"}}},
+		Headers:     []model.Field{{Name: "Authorization", Types: []string{"BearerAuth"}, Required: true}},
+	}}}
+	var learned learnResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&learned))
+	require.Equal(t, expected, learned)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, learnRoute, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	learned = learnResponse{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&learned))
+	require.Equal(t, expected, learned)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, learnRoute, strings.NewReader(`[{"path": `)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, promoteRoute, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	endpoint := `{"path": "/users/44", "method": "GET", "headers": [{"name": "Authorization", "value": "Bearer ghi"}]}`
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, validationRoute, strings.NewReader(endpoint)))
+	require.Equal(t, http.StatusOK, w.Code)
+	var report validate.ValidationReport
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	require.True(t, report.Valid)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, learnRoute, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Zero(t, srv.learner.Observed())
+}
+
+func TestStats(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	w := httptest.NewRecorder()
+	models := `[{"path": "/users/{id}", "method": "GET", "path_params": [{"name": "id", "types": ["Int"], "required": true}],
+		"query_params": [{"name": "verbose", "types": ["Boolean"]}]}]`
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(models)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	requests := []struct {
+		route string
+		body  string
+	}{
+		{validationRoute, `{"path": "/users/1", "method": "GET"}`},
+		{validationRoute, `{"path": "/users/abc", "method": "GET", "query_params": [{"name": "verbose", "value": "yes"}]}`},
+		{batchRoute, `[{"path": "/users/2", "method": "GET", "query_params": [{"name": "verbose", "value": "no"}]}, {"path": "/unmodelled", "method": "GET"}]`},
+	}
+	for _, request := range requests {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, request.route, strings.NewReader(request.body)))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, statsRoute+"?path_prefix=/users", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var endpointStats []stats.EndpointStats
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&endpointStats))
+	require.Len(t, endpointStats, 1)
+	require.Equal(t, "/users/{id}", endpointStats[0].Path)
+	require.Equal(t, stats.WindowStats{Window: "5m", Requests: 3, Invalid: 2, Errors: []stats.ErrorStats{
+		{Section: "query_params", Path: "/verbose", ErrorType: validate.ErrMismatchType, Count: 2},
+		{Section: "path_params", Path: "/id", ErrorType: validate.ErrMismatchType, Count: 1},
+	}}, endpointStats[0].Windows[0])
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, statsRoute, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, statsRoute, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `[]`, w.Body.String())
+}
+
+func TestTypes(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{
+			name:   "Invalid type",
+			method: http.MethodPut,
+			target: typesRoute,
+			body:   `[{"name": "SKU", "base": "Text"}]`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Model with undefined type",
+			method: http.MethodPut,
+			target: modelRoute,
+			body:   `[{"path": "/items", "method": "POST", "body": [{"name": "sku", "types": ["SKU"]}]}]`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Define types",
+			method: http.MethodPut,
+			target: typesRoute,
+			body:   `[{"name": "SKU", "base": "String", "pattern": "^[A-Z]{3}-[0-9]+$"}]`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Model with defined type",
+			method: http.MethodPut,
+			target: modelRoute,
+			body:   `[{"path": "/items", "method": "POST", "body": [{"name": "sku", "types": ["SKU"]}]}]`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "Model file with types",
+			method: http.MethodPut,
+			target: modelRoute,
+			body: `{"types": [{"name": "Percent", "base": "Int", "minimum": 0, "maximum": 100}],
+				"models": [{"path": "/items", "method": "GET", "query_params": [{"name": "discount", "types": ["Percent"]}]}]}`,
+			status: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, typesRoute, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var definitions []model.TypeDefinition
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&definitions))
+	require.Len(t, definitions, 2)
+	require.Equal(t, "Percent", definitions[0].Name)
+
+	validations := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{endpoint: `{"path": "/items", "method": "POST", "body": [{"name": "sku", "value": "ABC-1"}]}`, valid: true},
+		{endpoint: `{"path": "/items", "method": "POST", "body": [{"name": "sku", "value": "abc"}]}`, valid: false},
+		{endpoint: `{"path": "/items", "method": "GET", "query_params": [{"name": "discount", "value": "20"}]}`, valid: true},
+		{endpoint: `{"path": "/items", "method": "GET", "query_params": [{"name": "discount", "value": "120"}]}`, valid: false},
+	}
+	for _, validation := range validations {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, validationRoute, strings.NewReader(validation.endpoint)))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var report validate.ValidationReport
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+		assert.Equal(t, validation.valid, report.Valid, validation.endpoint)
+	}
+}
+
+func TestValidateNumeric(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	models := `[{
+		"path": "/orders/{id}",
+		"method": "POST",
+		"path_params": [{"name": "id", "types": ["Int64"], "required": true}],
+		"query_params": [{"name": "limit", "types": ["Int32"]}],
+		"headers": [{"name": "X-Ratio", "types": ["Float"]}],
+		"body": [
+			{"name": "order_type", "types": ["Int"], "required": true},
+			{"name": "weight", "types": ["Float"]},
+			{"name": "amount", "types": ["Decimal"]}
+		]
+	}]`
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(models)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	tests := []struct {
+		name     string
+		endpoint string
+		invalid  []string
+	}{
+		{
+			name: "Numbers",
+			endpoint: `{"path": "/orders/9007199254740993", "method": "POST",
+				"query_params": [{"name": "limit", "value": "10"}],
+				"headers": [{"name": "X-Ratio", "value": " 0.5 "}],
+				"body": [
+					{"name": "order_type", "value": 3},
+					{"name": "weight", "value": 1.25},
+					{"name": "amount", "value": 12345678901234567890.123456789}
+				]}`,
+		},
+		{
+			name: "Integral float",
+			endpoint: `{"path": "/orders/1", "method": "POST",
+				"body": [{"name": "order_type", "value": 3.0}]}`,
+		},
+		{
+			name: "Wrong numbers",
+			endpoint: `{"path": "/orders/abc", "method": "POST",
+				"query_params": [{"name": "limit", "value": "2147483648"}],
+				"headers": [{"name": "X-Ratio", "value": "half"}],
+				"body": [
+					{"name": "order_type", "value": 3.5},
+					{"name": "weight", "value": "1.25"}
+				]}`,
+			invalid: []string{"/id", "/limit", "/X-Ratio", "/order_type", "/weight"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, validationRoute, strings.NewReader(tt.endpoint)))
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var report validate.ValidationReport
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+
+			var invalid []string
+			for _, section := range [][]validate.ValidationError{report.PathParams, report.QueryParams, report.Headers, report.Body} {
+				for _, validationError := range section {
+					invalid = append(invalid, validationError.Path)
+				}
+			}
+			assert.Equal(t, tt.invalid, invalid)
+			assert.Equal(t, len(tt.invalid) == 0, report.Valid)
+		})
+	}
+}
+
+// TestConcurrentModelUploadAndValidate exercises concurrent PUT and validate traffic,
+// run it with -race to detect unsynchronized access to the store
+func TestConcurrentModelUploadAndValidate(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	models, err := os.ReadFile("test_data/models.json")
+	require.NoError(t, err)
+	endpoint, err := os.ReadFile("test_data/endpointReqValid.json")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, bytes.NewReader(models)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	const requests = 50
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < requests; j++ {
+				w := httptest.NewRecorder()
+				srv.ServeHTTP(w, httptest.NewRequest(http.MethodPut, modelRoute, bytes.NewReader(models)))
+				if w.Code != http.StatusOK {
+					t.Errorf("model upload failed with status %d", w.Code)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < requests; j++ {
+				w := httptest.NewRecorder()
+				srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, validationRoute, bytes.NewReader(endpoint)))
+
+				var report validate.ValidationReport
+				if err := json.NewDecoder(w.Body).Decode(&report); err != nil || !report.Valid {
+					t.Errorf("expected valid report, got %+v (err: %v)", report, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestModelHistory(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	srv := NewServer(db)
+
+	for _, body := range []string{
+		`[{"path": "/users/info", "method": "GET", "headers": [{"name": "Authorization", "types": ["String"], "required": true}]}]`,
+		`[{"path": "/users/info", "method": "GET", "headers": []}]`,
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, modelRoute, strings.NewReader(body))
+		r.Header.Set("X-Author", "alice")
+		srv.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/history/GET/users/info", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var revisions []model.Revision
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&revisions))
+	require.Len(t, revisions, 2)
+	require.Equal(t, "alice", revisions[1].Author)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/history/GET/users/info?from=1&to=2", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var changes []model.Change
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&changes))
+	require.Len(t, changes, 1)
+	require.Equal(t, model.ChangeRemoved, changes[0].Change)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/rollback/GET/users/info", strings.NewReader(`{"revision": 1}`)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/rollback/GET/users/info", strings.NewReader(`{"revision": 9}`)))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	srv.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/model/GET/users/info", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var endpointModel model.EndpointModel
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&endpointModel))
+	require.Contains(t, endpointModel.Headers, "Authorization")
+}
+
 func TestRespond(t *testing.T) {
 	tests := []struct {
 		status int
@@ -94,23 +844,23 @@ func TestRespond(t *testing.T) {
 				Path:   "/users/info",
 				Method: "GET",
 				QueryParams: []validate.ValidationError{
-					{FieldName: "QueryParam1", ErrorType: validate.ErrMismatchType, ExpectedType: "String", ErrorValue: 42},
-					{FieldName: "QueryParam2", ErrorType: validate.ErrMismatchType, ExpectedType: "Int", ErrorValue: true},
-					{FieldName: "QueryParam3", ErrorType: validate.ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "hello world"},
+					{FieldName: "QueryParam1", Path: "/QueryParam1", ErrorType: validate.ErrMismatchType, ExpectedType: "String", ErrorValue: 42},
+					{FieldName: "QueryParam2", Path: "/QueryParam2", ErrorType: validate.ErrMismatchType, ExpectedType: "Int", ErrorValue: true},
+					{FieldName: "QueryParam3", Path: "/QueryParam3", ErrorType: validate.ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "hello world"},
 				},
 				Headers: []validate.ValidationError{
-					{FieldName: "Header1", ErrorType: validate.ErrMismatchType, ExpectedType: "String", ErrorValue: 42},
-					{FieldName: "Header2", ErrorType: validate.ErrMismatchType, ExpectedType: "Int", ErrorValue: true},
-					{FieldName: "Header3", ErrorType: validate.ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "hello world"},
+					{FieldName: "Header1", Path: "/Header1", ErrorType: validate.ErrMismatchType, ExpectedType: "String", ErrorValue: 42},
+					{FieldName: "Header2", Path: "/Header2", ErrorType: validate.ErrMismatchType, ExpectedType: "Int", ErrorValue: true},
+					{FieldName: "Header3", Path: "/Header3", ErrorType: validate.ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "hello world"},
 				},
 				Body: []validate.ValidationError{
-					{FieldName: "Body1", ErrorType: validate.ErrMismatchType, ExpectedType: "String", ErrorValue: 42},
-					{FieldName: "Body2", ErrorType: validate.ErrMismatchType, ExpectedType: "Int", ErrorValue: true},
-					{FieldName: "Body3", ErrorType: validate.ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "hello world"},
+					{FieldName: "Body1", Path: "/Body1", ErrorType: validate.ErrMismatchType, ExpectedType: "String", ErrorValue: 42},
+					{FieldName: "Body2", Path: "/Body2", ErrorType: validate.ErrMismatchType, ExpectedType: "Int", ErrorValue: true},
+					{FieldName: "Body3", Path: "/Body3", ErrorType: validate.ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "hello world"},
 				},
 				Valid: false,
 			},
-			result: `{"Path":"/users/info","Method":"GET","QueryParams":[{"FieldName":"QueryParam1","ErrorType":"value type mismatch","ExpectedType":"String","ErrorValue":42},{"FieldName":"QueryParam2","ErrorType":"value type mismatch","ExpectedType":"Int","ErrorValue":true},{"FieldName":"QueryParam3","ErrorType":"value type mismatch","ExpectedType":"Boolean","ErrorValue":"hello world"}],"Headers":[{"FieldName":"Header1","ErrorType":"value type mismatch","ExpectedType":"String","ErrorValue":42},{"FieldName":"Header2","ErrorType":"value type mismatch","ExpectedType":"Int","ErrorValue":true},{"FieldName":"Header3","ErrorType":"value type mismatch","ExpectedType":"Boolean","ErrorValue":"hello world"}],"Body":[{"FieldName":"Body1","ErrorType":"value type mismatch","ExpectedType":"String","ErrorValue":42},{"FieldName":"Body2","ErrorType":"value type mismatch","ExpectedType":"Int","ErrorValue":true},{"FieldName":"Body3","ErrorType":"value type mismatch","ExpectedType":"Boolean","ErrorValue":"hello world"}],"Valid":false}`,
+			result: `{"Path":"/users/info","Method":"GET","PathParams":null,"QueryParams":[{"FieldName":"QueryParam1","Path":"/QueryParam1","ErrorType":"value type mismatch","ExpectedType":"String","ErrorValue":42},{"FieldName":"QueryParam2","Path":"/QueryParam2","ErrorType":"value type mismatch","ExpectedType":"Int","ErrorValue":true},{"FieldName":"QueryParam3","Path":"/QueryParam3","ErrorType":"value type mismatch","ExpectedType":"Boolean","ErrorValue":"hello world"}],"Headers":[{"FieldName":"Header1","Path":"/Header1","ErrorType":"value type mismatch","ExpectedType":"String","ErrorValue":42},{"FieldName":"Header2","Path":"/Header2","ErrorType":"value type mismatch","ExpectedType":"Int","ErrorValue":true},{"FieldName":"Header3","Path":"/Header3","ErrorType":"value type mismatch","ExpectedType":"Boolean","ErrorValue":"hello world"}],"Body":[{"FieldName":"Body1","Path":"/Body1","ErrorType":"value type mismatch","ExpectedType":"String","ErrorValue":42},{"FieldName":"Body2","Path":"/Body2","ErrorType":"value type mismatch","ExpectedType":"Int","ErrorValue":true},{"FieldName":"Body3","Path":"/Body3","ErrorType":"value type mismatch","ExpectedType":"Boolean","ErrorValue":"hello world"}],"Rules":null,"Valid":false}`,
 			/*
 							{
 				    "Path": "/users/info",
@@ -0,0 +1,314 @@
+/*
+Validator validates captured requests offline against a models file:
+
+	validator -models models.json requests.jsonl
+
+Requests are read from a JSON array or a JSONL file of endpoints ("-" reads stdin). The summary and
+the per-request reports are printed as JSON, and the exit code is 1 when any request is invalid,
+so it can gate CI pipelines. Models with unknown types or other lint problems fail the run
+unless -lenient is set.
+
+With -learn no models are needed, candidate models are learned from the requests instead and
+printed as a JSON array of models, ready for review and upload:
+
+	validator -learn [-required-ratio 0.9] requests.jsonl
+
+With -har the requests are read from a HAR file, e.g. a recorded browser session, keeping the
+entries of the host and path prefix given. Only the headers the models declare are validated,
+and responses are validated too when their endpoint models them. Entries that cannot be read
+are skipped with a warning and counted as skipped:
+
+	validator -models models.json -har [-host api.example.com] [-path-prefix /v1] session.har
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/evgeniron/API-Validator/har"
+	"github.com/evgeniron/API-Validator/learn"
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/evgeniron/API-Validator/validate"
+)
+
+const (
+	exitValid   = 0
+	exitInvalid = 1
+	exitError   = 2
+)
+
+type Summary struct {
+	Total      int `json:"total"`
+	Valid      int `json:"valid"`
+	Invalid    int `json:"invalid"`
+	Unmodelled int `json:"unmodelled"`
+	Skipped    int `json:"skipped,omitempty"`
+}
+
+type Result struct {
+	Index          int                        `json:"index"`
+	Report         *validate.ValidationReport `json:"report"`
+	ResponseReport *validate.ResponseReport   `json:"response_report,omitempty"`
+}
+
+type Output struct {
+	Summary Summary  `json:"summary"`
+	Reports []Result `json:"reports"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validator", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	modelsPath := flags.String("models", "", "path of the models file, a JSON array of endpoint models")
+	onlyInvalid := flags.Bool("only-invalid", false, "print only the reports of invalid requests")
+	lenient := flags.Bool("lenient", false, "accept models with lint problems, printing them as warnings")
+	learnModels := flags.Bool("learn", false, "learn candidate models from the requests instead of validating them")
+	requiredRatio := flags.Float64("required-ratio", learn.DefaultOptions().RequiredRatio, "share of the requests a learned field must appear in to be required")
+	harInput := flags.Bool("har", false, "read the requests from a HAR file")
+	host := flags.String("host", "", "keep the HAR entries of the host only")
+	pathPrefix := flags.String("path-prefix", "", "keep the HAR entries whose path starts with the prefix only")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	if (*modelsPath != "") == *learnModels || flags.NArg() != 1 || (!*harInput && (*host != "" || *pathPrefix != "")) {
+		fmt.Fprintf(stderr, "usage: validator -models models.json [-only-invalid] [-lenient] requests.jsonl\n")
+		fmt.Fprintf(stderr, "       validator -learn [-required-ratio ratio] requests.jsonl\n")
+		fmt.Fprintf(stderr, "       validator (-models models.json | -learn) -har [-host host] [-path-prefix prefix] session.har\n")
+		return exitError
+	}
+
+	input := stdin
+	if flags.Arg(0) != "-" {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "error opening requests: %s\n", err)
+			return exitError
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var next func() (*capture, error)
+	skipped := 0
+	if *harInput {
+		records, err := har.Read(input, har.Options{Host: *host, PathPrefix: *pathPrefix})
+		if err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return exitError
+		}
+		readable := records[:0]
+		for _, record := range records {
+			if record.Err != nil {
+				fmt.Fprintf(stderr, "skipped %s\n", record.Err)
+				skipped++
+				continue
+			}
+			readable = append(readable, record)
+		}
+		next = harCaptures(readable)
+	} else {
+		next = endpointCaptures(validate.NewEndpointDecoder(input))
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+
+	if *learnModels {
+		models, err := learnAll(next, learn.Options{RequiredRatio: *requiredRatio})
+		if err != nil {
+			fmt.Fprintf(stderr, "%s\n", err)
+			return exitError
+		}
+		if err := encoder.Encode(models); err != nil {
+			fmt.Fprintf(stderr, "error writing models: %s\n", err)
+			return exitError
+		}
+		return exitValid
+	}
+
+	db, err := loadModels(*modelsPath, *lenient, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return exitError
+	}
+
+	output, err := validateAll(db, next, *onlyInvalid)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return exitError
+	}
+	output.Summary.Skipped = skipped
+
+	if err := encoder.Encode(output); err != nil {
+		fmt.Fprintf(stderr, "error writing reports: %s\n", err)
+		return exitError
+	}
+
+	if output.Summary.Invalid > 0 {
+		return exitInvalid
+	}
+	return exitValid
+}
+
+// loadModels stores the models of the file in memory. Lint problems are printed to
+// stderr and fail the load unless lenient is set.
+func loadModels(path string, lenient bool, stderr io.Writer) (*store.Store, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening models: %w", err)
+	}
+	defer file.Close()
+
+	models, err := model.DecodeModelFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defined := make(map[string]bool, len(models.Types))
+	for i := range models.Types {
+		if _, err := validate.CompileType(&models.Types[i]); err != nil {
+			return nil, err
+		}
+		defined[models.Types[i].Name] = true
+	}
+
+	problems := model.Lint(models.Models, func(name string) bool {
+		return defined[name] || validate.KnownType(name)
+	})
+	problems = append(problems, validate.LintRules(models.Models)...)
+	for _, problem := range problems {
+		fmt.Fprintf(stderr, "models[%d] %s: %s\n", problem.Index, problem.Location, problem.Message)
+	}
+	if len(problems) > 0 && !lenient {
+		return nil, fmt.Errorf("invalid models: %d problems found, use -lenient to accept them", len(problems))
+	}
+
+	db, err := store.NewInMemoryDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := model.StoreModelFile(db, models, "validator"); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// capture is a captured request, with its response when it was recorded. Headers
+// of live captures hold whatever the client sent, only the modelled ones are
+// validated.
+type capture struct {
+	index    int
+	endpoint *validate.Endpoint
+	response *validate.Response
+	live     bool
+}
+
+// endpointCaptures returns the endpoints of the stream one at a time, and io.EOF
+// when the stream is exhausted
+func endpointCaptures(decoder *validate.EndpointDecoder) func() (*capture, error) {
+	index := 0
+	return func() (*capture, error) {
+		endpoint, err := decoder.Decode()
+		if err != nil {
+			return nil, err
+		}
+		index++
+		return &capture{index: index - 1, endpoint: endpoint}, nil
+	}
+}
+
+// harCaptures returns the records one at a time, indexed by their HAR entry
+func harCaptures(records []har.Record) func() (*capture, error) {
+	return func() (*capture, error) {
+		if len(records) == 0 {
+			return nil, io.EOF
+		}
+		record := records[0]
+		records = records[1:]
+		return &capture{index: record.Index, endpoint: record.Endpoint, response: record.Response, live: true}, nil
+	}
+}
+
+// validateAll validates every capture. Captures without a model are counted as
+// unmodelled, like the server does not report on them. Responses are validated
+// when their endpoint models responses, an invalid response makes the capture
+// invalid.
+func validateAll(db model.Reader, next func() (*capture, error), onlyInvalid bool) (*Output, error) {
+	output := &Output{Reports: []Result{}}
+	for {
+		capture, err := next()
+		if errors.Is(err, io.EOF) {
+			return output, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		output.Summary.Total++
+
+		endpoint := capture.endpoint
+		endpointModel, err := model.GetModel(db, endpoint.Path, endpoint.Method)
+		if err != nil {
+			var e *store.RecordNotFoundError
+			if errors.As(err, &e) {
+				output.Summary.Unmodelled++
+				continue
+			}
+			return nil, err
+		}
+
+		if capture.live {
+			endpoint.Headers = validate.ModelHeaders(endpoint.Headers, endpointModel.Headers)
+		}
+		report, err := validate.ValidateReportWithTypes(endpoint, endpointModel, db)
+		if err != nil {
+			return nil, fmt.Errorf("error validating endpoint %d: %w", capture.index, err)
+		}
+		result := Result{Index: capture.index, Report: report}
+		valid := report.Valid
+
+		if capture.response != nil && len(endpointModel.Responses) > 0 {
+			result.ResponseReport, err = validate.ValidateResponse(capture.response, endpointModel, db)
+			if err != nil {
+				return nil, fmt.Errorf("error validating response %d: %w", capture.index, err)
+			}
+			valid = valid && result.ResponseReport.Valid
+		}
+
+		if valid {
+			output.Summary.Valid++
+		} else {
+			output.Summary.Invalid++
+		}
+
+		if !valid || !onlyInvalid {
+			output.Reports = append(output.Reports, result)
+		}
+	}
+}
+
+// learnAll learns the candidate models of every capture
+func learnAll(next func() (*capture, error), options learn.Options) ([]model.Endpoint, error) {
+	learner := learn.NewLearner(options)
+	for {
+		capture, err := next()
+		if errors.Is(err, io.EOF) {
+			return learner.Models(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		learner.Observe(capture.endpoint)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/validate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		exitCode int
+		summary  Summary
+		reports  int
+	}{
+		{
+			name:     "Valid JSONL",
+			args:     []string{"-models", "test_data/models.json", "test_data/valid.jsonl"},
+			exitCode: exitValid,
+			summary:  Summary{Total: 3, Valid: 2, Unmodelled: 1},
+			reports:  2,
+		},
+		{
+			name:     "Invalid JSON array",
+			args:     []string{"-models", "test_data/models.json", "test_data/invalid.json"},
+			exitCode: exitInvalid,
+			summary:  Summary{Total: 2, Valid: 1, Invalid: 1},
+			reports:  2,
+		},
+		{
+			name:     "Only invalid reports",
+			args:     []string{"-models", "test_data/models.json", "-only-invalid", "test_data/invalid.json"},
+			exitCode: exitInvalid,
+			summary:  Summary{Total: 2, Valid: 1, Invalid: 1},
+			reports:  1,
+		},
+		{
+			name:     "Stdin",
+			args:     []string{"-models", "test_data/models.json", "-"},
+			stdin:    `{"path": "/users/info", "method": "GET", "headers": []}`,
+			exitCode: exitInvalid,
+			summary:  Summary{Total: 1, Invalid: 1},
+			reports:  1,
+		},
+		{
+			name:     "Lenient models",
+			args:     []string{"-models", "test_data/unknown_types.json", "-lenient", "-"},
+			stdin:    `{"path": "/users/info", "method": "GET", "headers": [{"name": "Authorization", "value": "token"}]}`,
+			exitCode: exitValid,
+			summary:  Summary{Total: 1, Valid: 1},
+			reports:  1,
+		},
+		{
+			name:     "HAR",
+			args:     []string{"-models", "test_data/har_models.json", "-har", "test_data/session.har"},
+			exitCode: exitInvalid,
+			summary:  Summary{Total: 4, Valid: 1, Invalid: 2, Unmodelled: 1},
+			reports:  3,
+		},
+		{
+			name:     "HAR host",
+			args:     []string{"-models", "test_data/har_models.json", "-har", "-host", "api.example.com", "test_data/session.har"},
+			exitCode: exitInvalid,
+			summary:  Summary{Total: 3, Valid: 1, Invalid: 2},
+			reports:  3,
+		},
+		{
+			name:     "HAR with unreadable entry",
+			args:     []string{"-models", "test_data/har_models.json", "-har", "-"},
+			stdin:    `{"log": {"entries": [{"request": {"method": "GET", "url": "%zz"}}]}}`,
+			exitCode: exitValid,
+			summary:  Summary{Skipped: 1},
+			reports:  0,
+		},
+		{
+			name:     "HAR path prefix",
+			args:     []string{"-models", "test_data/har_models.json", "-har", "-path-prefix", "/users/info", "-only-invalid", "test_data/session.har"},
+			exitCode: exitInvalid,
+			summary:  Summary{Total: 2, Valid: 1, Invalid: 1},
+			reports:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			require.Equal(t, tt.exitCode, exitCode, stderr.String())
+
+			var output Output
+			require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+			require.Equal(t, tt.summary, output.Summary)
+			require.Len(t, output.Reports, tt.reports)
+		})
+	}
+}
+
+func TestRunLearn(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := run([]string{"-learn", "-required-ratio", "0.5", "test_data/valid.jsonl"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, exitValid, exitCode, stderr.String())
+
+	var models []model.Endpoint
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &models))
+	require.Len(t, models, 2)
+	require.Equal(t, "/users/delete", models[0].Path)
+	require.Equal(t, "/users/info", models[1].Path)
+	require.Equal(t, []model.Field{{Name: "Authorization", Types: []string{"BearerAuth"}, Required: true}}, models[1].Headers)
+	require.Equal(t, []model.Field{
+		{Name: "user_id", Types: []string{"UUID"}, Required: true},
+		{Name: "with_extra_data", Types: []string{"Boolean"}, Required: true},
+	}, models[1].QueryParams)
+}
+
+func TestRunHAR(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	exitCode := run([]string{"-models", "test_data/har_models.json", "-har", "-host", "api.example.com", "test_data/session.har"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, exitInvalid, exitCode, stderr.String())
+
+	var output Output
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &output))
+	require.Len(t, output.Reports, 3)
+
+	// the reports are indexed by HAR entry, browser headers are not validated
+	require.Equal(t, 0, output.Reports[0].Index)
+	require.True(t, output.Reports[0].Report.Valid)
+	require.True(t, output.Reports[0].ResponseReport.Valid)
+
+	require.Equal(t, 1, output.Reports[1].Index)
+	require.True(t, output.Reports[1].Report.Valid)
+	require.Equal(t, []validate.ValidationError{{FieldName: "id", Path: "/id", ErrorType: validate.ErrMismatchType, ExpectedType: "Int", ErrorValue: "one"}}, output.Reports[1].ResponseReport.Body)
+
+	// responses of endpoints without response models are not validated
+	require.Equal(t, 3, output.Reports[2].Index)
+	require.False(t, output.Reports[2].Report.Valid)
+	require.Nil(t, output.Reports[2].ResponseReport)
+
+	stdout.Reset()
+	exitCode = run([]string{"-learn", "-har", "-host", "api.example.com", "test_data/session.har"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, exitValid, exitCode, stderr.String())
+
+	var models []model.Endpoint
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &models))
+	require.Len(t, models, 2)
+	require.Equal(t, "/users/create", models[0].Path)
+	require.Equal(t, []model.Field{{Name: "lastName", Types: []string{"String"}, Required: true}}, models[0].Body)
+	require.Equal(t, "/users/info", models[1].Path)
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+	}{
+		{name: "Missing models", args: []string{"test_data/valid.jsonl"}},
+		{name: "Missing requests", args: []string{"-models", "test_data/models.json"}},
+		{name: "Unknown models file", args: []string{"-models", "test_data/missing.json", "test_data/valid.jsonl"}},
+		{name: "Malformed requests", args: []string{"-models", "test_data/models.json", "-"}, stdin: `[{"path": `},
+		{name: "Unknown model types", args: []string{"-models", "test_data/unknown_types.json", "test_data/valid.jsonl"}},
+		{name: "Learn with models", args: []string{"-learn", "-models", "test_data/models.json", "test_data/valid.jsonl"}},
+		{name: "Learn malformed requests", args: []string{"-learn", "-"}, stdin: `[{"path": `},
+		{name: "Host without HAR", args: []string{"-models", "test_data/models.json", "-host", "api.example.com", "test_data/valid.jsonl"}},
+		{name: "Malformed HAR", args: []string{"-models", "test_data/models.json", "-har", "-"}, stdin: `{"log": `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			require.Equal(t, exitError, run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr))
+			require.NotEmpty(t, stderr.String())
+		})
+	}
+}
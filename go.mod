@@ -1,14 +1,14 @@
 module github.com/evgeniron/API-Validator
 
-go 1.19
+go 1.21
 
 require (
 	github.com/gorilla/mux v1.8.0
 	github.com/stretchr/testify v1.8.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
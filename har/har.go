@@ -0,0 +1,107 @@
+/*
+Package har reads HTTP Archive (HAR 1.2) files, as recorded by browsers and
+proxies, into the endpoints and responses the validator works with.
+
+Entries can be filtered by the host and path prefix of their request URL, so a
+recorded session keeps only the traffic of the API under test. An entry that
+cannot be read does not fail the archive, its record carries the error instead.
+*/
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/evgeniron/API-Validator/validate"
+)
+
+// Options filter the entries, empty options keep every entry
+type Options struct {
+	// Host matches the request host, with or without its port, case insensitively
+	Host       string
+	PathPrefix string
+}
+
+// Record is an entry of the archive. Index is the position of the entry in the
+// archive, Response is nil for entries without a response. Err is set for entries
+// that cannot be read, their Endpoint and Response are nil.
+type Record struct {
+	Index    int
+	Endpoint *validate.Endpoint
+	Response *validate.Response
+	Err      error
+}
+
+type archive struct {
+	Log struct {
+		Entries []json.RawMessage `json:"entries"`
+	} `json:"log"`
+}
+
+// Read returns the records of the entries of the archive that pass the filters.
+// Only an archive that is not valid JSON fails the read, entries that cannot be
+// read are returned with their error. Entries whose URL cannot be read are
+// returned whatever the filters.
+func Read(r io.Reader, options Options) ([]Record, error) {
+	var har archive
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return nil, fmt.Errorf("error decoding HAR: %w", err)
+	}
+
+	records := []Record{}
+	for i, raw := range har.Log.Entries {
+		var entry validate.HAREntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			records = append(records, Record{Index: i, Err: fmt.Errorf("error decoding HAR entry %d: %w", i, err)})
+			continue
+		}
+
+		requestURL, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			records = append(records, Record{Index: i, Err: fmt.Errorf("HAR entry %d: invalid URL: %w", i, err)})
+			continue
+		}
+		if !options.matches(requestURL) {
+			continue
+		}
+
+		record, err := convert(&entry)
+		if err != nil {
+			record = Record{Err: fmt.Errorf("HAR entry %d: %w", i, err)}
+		}
+		record.Index = i
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (o Options) matches(requestURL *url.URL) bool {
+	if o.Host != "" && !strings.EqualFold(requestURL.Host, o.Host) && !strings.EqualFold(requestURL.Hostname(), o.Host) {
+		return false
+	}
+	return strings.HasPrefix(requestURL.Path, o.PathPrefix)
+}
+
+func convert(entry *validate.HAREntry) (Record, error) {
+	request, err := entry.HTTPRequest()
+	if err != nil {
+		return Record{}, err
+	}
+	endpoint, err := validate.ParseRequest(request)
+	if err != nil {
+		return Record{}, err
+	}
+
+	resp, err := entry.HTTPResponse(request)
+	if err != nil || resp == nil {
+		return Record{Endpoint: endpoint}, err
+	}
+	response, err := validate.ParseHTTPResponse(resp)
+	if err != nil {
+		return Record{}, fmt.Errorf("response: %w", err)
+	}
+	return Record{Endpoint: endpoint, Response: response}, nil
+}
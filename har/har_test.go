@@ -0,0 +1,121 @@
+package har
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		indexes []int
+	}{
+		{name: "All entries", indexes: []int{0, 1, 2, 3}},
+		{name: "Host", options: Options{Host: "API.example.com"}, indexes: []int{0, 2, 3}},
+		{name: "Host with port", options: Options{Host: "api.example.com:443"}, indexes: []int{2}},
+		{name: "Host and path prefix", options: Options{Host: "api.example.com", PathPrefix: "/v1/"}, indexes: []int{0, 2}},
+		{name: "No match", options: Options{PathPrefix: "/v2"}, indexes: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := os.Open("test_data/session.har")
+			require.NoError(t, err)
+			defer file.Close()
+
+			records, err := Read(file, tt.options)
+			require.NoError(t, err)
+
+			indexes := []int{}
+			for _, record := range records {
+				indexes = append(indexes, record.Index)
+			}
+			assert.Equal(t, tt.indexes, indexes)
+		})
+	}
+}
+
+func TestReadRecords(t *testing.T) {
+	file, err := os.Open("test_data/session.har")
+	require.NoError(t, err)
+	defer file.Close()
+
+	records, err := Read(file, Options{Host: "api.example.com"})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	get := records[0]
+	assert.Equal(t, "/v1/users/1", get.Endpoint.Path)
+	assert.Equal(t, http.MethodGet, get.Endpoint.Method)
+	assert.Equal(t, []validate.Field{{Name: "verbose", Value: "true"}}, get.Endpoint.QueryParams)
+	assert.Equal(t, []validate.Field{{Name: "Accept", Value: "application/json"}}, get.Endpoint.Headers)
+	require.NotNil(t, get.Response)
+	assert.Equal(t, 200, get.Response.Status)
+	assert.Equal(t, "/v1/users/1", get.Response.Path)
+	assert.Equal(t, []validate.Field{{Name: "id", Value: json.Number("1")}, {Name: "name", Value: "bob"}}, get.Response.Body)
+
+	post := records[1]
+	assert.Equal(t, []validate.Field{{Name: "Content-Type", Value: "application/json"}}, post.Endpoint.Headers)
+	assert.Equal(t, []validate.Field{{Name: "name", Value: "alice"}}, post.Endpoint.Body)
+	require.NotNil(t, post.Response)
+	assert.Equal(t, []validate.Field{{Name: "id", Value: json.Number("2")}}, post.Response.Body)
+
+	login := records[2]
+	assert.Equal(t, []validate.Field{{Name: "user", Value: "bob"}}, login.Endpoint.Body)
+	assert.Nil(t, login.Response)
+}
+
+func TestReadErrors(t *testing.T) {
+	_, err := Read(strings.NewReader(`log`), Options{})
+	assert.Error(t, err)
+}
+
+func TestReadEntryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry string
+	}{
+		{name: "Invalid entry", entry: `{"request": []}`},
+		{name: "Invalid URL", entry: `{"request": {"method": "GET", "url": "%zz"}}`},
+		{name: "Invalid body", entry: `{"request": {"method": "POST", "url": "https://example.com/users",
+			"postData": {"mimeType": "application/json", "text": "{"}}}`},
+		{name: "Invalid response content", entry: `{"request": {"method": "GET", "url": "https://example.com/users"},
+			"response": {"status": 200, "content": {"text": "!", "encoding": "base64"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a bad entry does not fail the entries around it
+			valid := `{"request": {"method": "GET", "url": "https://example.com/users"}}`
+			har := `{"log": {"entries": [` + valid + `, ` + tt.entry + `, ` + valid + `]}}`
+			records, err := Read(strings.NewReader(har), Options{})
+			require.NoError(t, err)
+			require.Len(t, records, 3)
+
+			assert.NoError(t, records[0].Err)
+			assert.NotNil(t, records[0].Endpoint)
+			assert.Error(t, records[1].Err)
+			assert.Equal(t, 1, records[1].Index)
+			assert.Nil(t, records[1].Endpoint)
+			assert.NoError(t, records[2].Err)
+			assert.Equal(t, 2, records[2].Index)
+		})
+	}
+}
+
+func TestReadNonObjectBody(t *testing.T) {
+	har := `{"log": {"entries": [{"request": {"method": "GET", "url": "https://example.com/users"},
+		"response": {"status": 200, "content": {"mimeType": "application/json", "text": "[{\"id\": 1}]"}}}]}}`
+	records, err := Read(strings.NewReader(har), Options{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.NoError(t, records[0].Err)
+	require.NotNil(t, records[0].Response)
+	assert.Empty(t, records[0].Response.Body)
+}
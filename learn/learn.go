@@ -0,0 +1,317 @@
+/*
+Package learn infers candidate endpoint models from captured requests.
+
+A Learner observes validate.Endpoint captures and keeps, per endpoint and field,
+how often the field appeared and how many of its values each built-in type
+accepted. The candidate models type every field with the most specific type that
+accepted all its values, and require the fields that appeared in enough of the
+captures. Concrete path segments that look like identifiers, integers or UUIDs,
+become path params, so /users/42 and /users/43 are learned as /users/{user_id}.
+At most maxEndpoints endpoints are learned, captures of further endpoints are
+ignored. Likewise at most maxFields fields are learned per section and object,
+further field names are ignored, as they come from the traffic too.
+*/
+package learn
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/validate"
+)
+
+// candidateTypes are the built-in types tried for every value, the most specific first
+var candidateTypes = []string{"UUID", "Date", "Email", "BearerAuth", "Boolean", "Int", "Decimal", "String", "File", "Object", "List"}
+
+// maxEndpoints bounds the endpoints learned, as request paths come from the traffic
+const maxEndpoints = 1000
+
+// maxFields bounds the fields learned per section and the properties per object
+const maxFields = 256
+
+// Options tune the inference
+type Options struct {
+	// RequiredRatio is the share of the captures a field must appear in to be
+	// required, 1 requires the fields that appeared in every capture
+	RequiredRatio float64
+}
+
+func DefaultOptions() Options {
+	return Options{RequiredRatio: 1}
+}
+
+// Learner accumulates the captures, it's safe for concurrent use
+type Learner struct {
+	mu        sync.Mutex
+	options   Options
+	observed  int
+	endpoints map[string]*endpointStats
+}
+
+func NewLearner(options Options) *Learner {
+	return &Learner{options: options, endpoints: make(map[string]*endpointStats)}
+}
+
+type endpointStats struct {
+	path        string
+	method      string
+	samples     int
+	pathParams  map[string]*fieldStats
+	queryParams map[string]*fieldStats
+	headers     map[string]*fieldStats
+	body        map[string]*fieldStats
+}
+
+// fieldStats counts the values of a field
+type fieldStats struct {
+	seen int
+	// matches counts the values accepted by each candidate type
+	matches map[string]int
+	// best counts the values by their most specific type, values no type accepts are untyped
+	best    map[string]int
+	untyped int
+
+	objects    int
+	properties map[string]*fieldStats
+	items      *fieldStats
+}
+
+func newFieldStats() *fieldStats {
+	return &fieldStats{matches: make(map[string]int), best: make(map[string]int)}
+}
+
+// Observe adds a capture to the learned models. Captures of a new endpoint are
+// ignored once maxEndpoints endpoints are learned.
+func (l *Learner) Observe(endpoint *validate.Endpoint) {
+	path, pathParams := templatePath(endpoint.Path)
+	method := strings.ToUpper(endpoint.Method)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := method + " " + path
+	stats, ok := l.endpoints[key]
+	if !ok {
+		if len(l.endpoints) >= maxEndpoints {
+			return
+		}
+		stats = &endpointStats{
+			path:        path,
+			method:      method,
+			pathParams:  make(map[string]*fieldStats),
+			queryParams: make(map[string]*fieldStats),
+			headers:     make(map[string]*fieldStats),
+			body:        make(map[string]*fieldStats),
+		}
+		l.endpoints[key] = stats
+	}
+
+	l.observed++
+	stats.samples++
+	observeFields(stats.pathParams, pathParams, true)
+	observeFields(stats.queryParams, endpoint.QueryParams, true)
+	observeFields(stats.headers, endpoint.Headers, true)
+	observeFields(stats.body, endpoint.Body, false)
+}
+
+// Observed returns the number of captures observed
+func (l *Learner) Observed() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.observed
+}
+
+// Reset forgets every capture
+func (l *Learner) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.observed = 0
+	l.endpoints = make(map[string]*endpointStats)
+}
+
+// Models returns the candidate models, sorted by path and method
+func (l *Learner) Models() []model.Endpoint {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	models := make([]model.Endpoint, 0, len(l.endpoints))
+	for _, stats := range l.endpoints {
+		models = append(models, model.Endpoint{
+			Path:        stats.path,
+			Method:      stats.method,
+			PathParams:  l.fields(stats.pathParams, stats.samples),
+			QueryParams: l.fields(stats.queryParams, stats.samples),
+			Headers:     l.fields(stats.headers, stats.samples),
+			Body:        l.fields(stats.body, stats.samples),
+		})
+	}
+
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].Path != models[j].Path {
+			return models[i].Path < models[j].Path
+		}
+		return models[i].Method < models[j].Method
+	})
+	return models
+}
+
+func observeFields(stats map[string]*fieldStats, fields []validate.Field, wire bool) {
+	for _, field := range fields {
+		if fs := fieldStatsOf(stats, field.Name); fs != nil {
+			fs.observe(field.Value, wire)
+		}
+	}
+}
+
+// fieldStatsOf returns the stats of the named field, adding them unless there are
+// maxFields fields already, then it returns nil
+func fieldStatsOf(stats map[string]*fieldStats, name string) *fieldStats {
+	fs, ok := stats[name]
+	if !ok {
+		if len(stats) >= maxFields {
+			return nil
+		}
+		fs = newFieldStats()
+		stats[name] = fs
+	}
+	return fs
+}
+
+func (fs *fieldStats) observe(value interface{}, wire bool) {
+	fs.seen++
+
+	best := ""
+	for _, typeName := range candidateTypes {
+		if validate.MatchesType(typeName, value, wire) {
+			fs.matches[typeName]++
+			if best == "" {
+				best = typeName
+			}
+		}
+	}
+	if best == "" {
+		fs.untyped++
+	} else {
+		fs.best[best]++
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		fs.objects++
+		if fs.properties == nil {
+			fs.properties = make(map[string]*fieldStats)
+		}
+		for name, property := range v {
+			if ps := fieldStatsOf(fs.properties, name); ps != nil {
+				ps.observe(property, false)
+			}
+		}
+	case []interface{}:
+		if fs.items == nil {
+			fs.items = newFieldStats()
+		}
+		for _, item := range v {
+			fs.items.observe(item, false)
+		}
+	}
+}
+
+// fields infers the fields of a section or object, out of the number of samples
+// the fields could have appeared in
+func (l *Learner) fields(stats map[string]*fieldStats, samples int) []model.Field {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]model.Field, 0, len(names))
+	for _, name := range names {
+		fs := stats[name]
+		field := l.value(fs)
+		field.Name = name
+		field.Required = samples > 0 && float64(fs.seen) >= l.options.RequiredRatio*float64(samples)
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// value infers the model of the field values. The values get the first candidate
+// type accepting all of them, values of different types get a branch per type and
+// fields with values no type accepts, like null, are left untyped.
+func (l *Learner) value(fs *fieldStats) model.Field {
+	var field model.Field
+	if fs.untyped > 0 {
+		return field
+	}
+
+	for _, typeName := range candidateTypes {
+		if fs.matches[typeName] == fs.seen {
+			field.Types = []string{typeName}
+			break
+		}
+	}
+
+	if field.Types == nil {
+		for _, typeName := range candidateTypes {
+			if fs.best[typeName] > 0 {
+				field.AnyOf = append(field.AnyOf, model.Field{Types: []string{typeName}})
+			}
+		}
+		return field
+	}
+
+	switch field.Types[0] {
+	case "Object":
+		field.Properties = l.fields(fs.properties, fs.objects)
+	case "List":
+		if fs.items != nil && fs.items.seen > 0 {
+			items := l.value(fs.items)
+			field.Items = &items
+		}
+	}
+	return field
+}
+
+// templatePath replaces the path segments that look like identifiers with path
+// params named after the preceding segment, and returns the params values
+func templatePath(path string) (string, []validate.Field) {
+	segments := strings.Split(path, "/")
+	var params []validate.Field
+	used := make(map[string]bool)
+	for i, segment := range segments {
+		if !isIdentifier(segment) {
+			continue
+		}
+
+		name := "id"
+		if i > 0 && segments[i-1] != "" && !strings.HasPrefix(segments[i-1], "{") {
+			name = strings.TrimSuffix(segments[i-1], "s") + "_id"
+		}
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s%d", strings.TrimRight(name, "0123456789"), n)
+		}
+		used[name] = true
+
+		params = append(params, validate.Field{Name: name, Value: segment})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func isIdentifier(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	return validate.UUIDValidator(segment) || validate.IntValidator(json.Number(segment))
+}
@@ -0,0 +1,180 @@
+package learn
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/evgeniron/API-Validator/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		template string
+		params   []validate.Field
+	}{
+		{path: "/users/info", template: "/users/info"},
+		{path: "/users/42", template: "/users/{user_id}", params: []validate.Field{{Name: "user_id", Value: "42"}}},
+		{
+			path:     "/users/42/orders/56ee9b7a-da8e-45a1-aade-a57761b912c4",
+			template: "/users/{user_id}/orders/{order_id}",
+			params: []validate.Field{
+				{Name: "user_id", Value: "42"},
+				{Name: "order_id", Value: "56ee9b7a-da8e-45a1-aade-a57761b912c4"},
+			},
+		},
+		{
+			path:     "/7/8/users/1.5",
+			template: "/{id}/{id2}/users/1.5",
+			params:   []validate.Field{{Name: "id", Value: "7"}, {Name: "id2", Value: "8"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			template, params := templatePath(tt.path)
+			assert.Equal(t, tt.template, template)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+}
+
+func TestLearner(t *testing.T) {
+	captures := []validate.Endpoint{
+		{
+			Path:        "/users/1",
+			Method:      "post",
+			QueryParams: []validate.Field{{Name: "limit", Value: "10"}},
+			Headers:     []validate.Field{{Name: "Authorization", Value: "Bearer abc"}},
+			Body: []validate.Field{
+				{Name: "email", Value: "a@example.com"},
+				{Name: "birthday", Value: "01-02-1990"},
+				{Name: "score", Value: json.Number("1")},
+				{Name: "code", Value: json.Number("7")},
+				{Name: "note", Value: nil},
+				{Name: "address", Value: map[string]interface{}{"city": "Haifa", "zip": json.Number("3200003")}},
+				{Name: "tags", Value: []interface{}{"a", "b"}},
+			},
+		},
+		{
+			Path:        "/users/2",
+			Method:      "POST",
+			QueryParams: []validate.Field{{Name: "limit", Value: "20"}, {Name: "dry_run", Value: "yes"}, {Name: "active", Value: "true"}},
+			Headers:     []validate.Field{{Name: "Authorization", Value: "Bearer def"}},
+			Body: []validate.Field{
+				{Name: "email", Value: "b@example.com"},
+				{Name: "birthday", Value: "31-12-1990"},
+				{Name: "score", Value: json.Number("2.5")},
+				{Name: "code", Value: "X7"},
+				{Name: "address", Value: map[string]interface{}{"city": "Tel Aviv"}},
+				{Name: "tags", Value: []interface{}{}},
+			},
+		},
+		{Path: "/health", Method: "GET"},
+	}
+
+	learner := NewLearner(DefaultOptions())
+	for i := range captures {
+		learner.Observe(&captures[i])
+	}
+	require.Equal(t, 3, learner.Observed())
+
+	expected := []model.Endpoint{
+		{Path: "/health", Method: "GET"},
+		{
+			Path:       "/users/{user_id}",
+			Method:     "POST",
+			PathParams: []model.Field{{Name: "user_id", Types: []string{"Int"}, Required: true}},
+			QueryParams: []model.Field{
+				{Name: "active", Types: []string{"Boolean"}},
+				{Name: "dry_run", Types: []string{"String"}},
+				{Name: "limit", Types: []string{"Int"}, Required: true},
+			},
+			Headers: []model.Field{{Name: "Authorization", Types: []string{"BearerAuth"}, Required: true}},
+			Body: []model.Field{
+				{Name: "address", Types: []string{"Object"}, Required: true, Properties: []model.Field{
+					{Name: "city", Types: []string{"String"}, Required: true},
+					{Name: "zip", Types: []string{"Int"}},
+				}},
+				{Name: "birthday", Types: []string{"Date"}, Required: true},
+				{Name: "code", Required: true, AnyOf: []model.Field{{Types: []string{"Int"}}, {Types: []string{"String"}}}},
+				{Name: "email", Types: []string{"Email"}, Required: true},
+				{Name: "note"},
+				{Name: "score", Types: []string{"Decimal"}, Required: true},
+				{Name: "tags", Types: []string{"List"}, Required: true, Items: &model.Field{Types: []string{"String"}}},
+			},
+		},
+	}
+	require.Equal(t, expected, learner.Models())
+
+	// the learned models validate the captures they were learned from
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	require.NoError(t, model.StoreModels(db, learner.Models(), "test"))
+	for i := range captures {
+		endpointModel, err := model.GetModel(db, captures[i].Path, strings.ToUpper(captures[i].Method))
+		require.NoError(t, err)
+		report, err := validate.ValidateReport(&captures[i], endpointModel)
+		require.NoError(t, err)
+		assert.True(t, report.Valid, "%+v", report)
+	}
+
+	learner.Reset()
+	assert.Equal(t, 0, learner.Observed())
+	assert.Empty(t, learner.Models())
+}
+
+func TestRequiredRatio(t *testing.T) {
+	learner := NewLearner(Options{RequiredRatio: 0.5})
+	for _, fields := range [][]validate.Field{
+		{{Name: "a", Value: "x"}, {Name: "b", Value: "x"}},
+		{{Name: "a", Value: "x"}},
+		{{Name: "a", Value: "x"}, {Name: "b", Value: "x"}, {Name: "c", Value: "x"}},
+	} {
+		learner.Observe(&validate.Endpoint{Path: "/items", Method: "GET", QueryParams: fields})
+	}
+
+	models := learner.Models()
+	require.Len(t, models, 1)
+	assert.Equal(t, []model.Field{
+		{Name: "a", Types: []string{"String"}, Required: true},
+		{Name: "b", Types: []string{"String"}, Required: true},
+		{Name: "c", Types: []string{"String"}},
+	}, models[0].QueryParams)
+}
+
+func TestMaxEndpoints(t *testing.T) {
+	learner := NewLearner(DefaultOptions())
+	for i := 0; i < maxEndpoints+10; i++ {
+		learner.Observe(&validate.Endpoint{Path: fmt.Sprintf("/items-%d", i), Method: "GET"})
+	}
+	// known endpoints are still learned
+	learner.Observe(&validate.Endpoint{Path: "/items-0", Method: "GET"})
+
+	assert.Equal(t, maxEndpoints+1, learner.Observed())
+	assert.Len(t, learner.Models(), maxEndpoints)
+}
+
+func TestMaxFields(t *testing.T) {
+	learner := NewLearner(DefaultOptions())
+	for i := 0; i < maxFields+10; i++ {
+		name := fmt.Sprintf("f%d", i)
+		learner.Observe(&validate.Endpoint{
+			Path:        "/items",
+			Method:      "POST",
+			QueryParams: []validate.Field{{Name: name, Value: "x"}},
+			Body:        []validate.Field{{Name: "item", Value: map[string]interface{}{name: "x"}}},
+		})
+	}
+
+	models := learner.Models()
+	require.Len(t, models, 1)
+	assert.Len(t, models[0].QueryParams, maxFields)
+	require.Len(t, models[0].Body, 1)
+	assert.Len(t, models[0].Body[0].Properties, maxFields)
+}
@@ -0,0 +1,150 @@
+/*
+Package middleware validates the requests of a Go service in-process, against the
+same endpoint models the validator server keeps.
+
+The middleware wraps any http.Handler, looks up the model of every request in a
+model store, and applies its policy to the validation report:
+
+	Reject    invalid requests are answered with 400 and the validation report
+	Log       invalid requests are logged and passed on
+	Annotate  requests are passed on with their report in the request context
+
+Requests without a model are passed on as they are.
+*/
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/validate"
+)
+
+type Policy string
+
+const (
+	Reject   Policy = "reject"
+	Log      Policy = "log"
+	Annotate Policy = "annotate"
+)
+
+type Options struct {
+	// Policy defaults to Reject
+	Policy Policy
+	// Snapshot returns the reader a request is validated with, e.g. a snapshot of
+	// the store, so a request is judged on a single version of the models. The
+	// models are read from db directly when it is nil.
+	Snapshot func() model.Reader
+	// OnReport is called with the report of every validated request, before the
+	// policy is applied
+	OnReport func(r *http.Request, report *validate.ValidationReport)
+	// Logger receives the invalid requests under the Log policy, and the requests
+	// that could not be parsed under every policy but Reject
+	Logger *log.Logger
+}
+
+func DefaultOptions() Options {
+	return Options{Policy: Reject, Logger: log.Default()}
+}
+
+type contextKey struct{}
+
+// ReportFromContext returns the report of a request passed on under the Annotate
+// policy
+func ReportFromContext(ctx context.Context) (*validate.ValidationReport, bool) {
+	report, ok := ctx.Value(contextKey{}).(*validate.ValidationReport)
+	return report, ok
+}
+
+// New returns a middleware validating the requests against the models of db,
+// or of the readers returned by options.Snapshot
+func New(db model.Reader, options Options) (func(http.Handler) http.Handler, error) {
+	switch options.Policy {
+	case "":
+		options.Policy = Reject
+	case Reject, Log, Annotate:
+	default:
+		return nil, fmt.Errorf("unknown policy %q", options.Policy)
+	}
+	if options.Logger == nil {
+		options.Logger = log.Default()
+	}
+	return func(next http.Handler) http.Handler {
+		return &validator{db: db, options: options, next: next}
+	}, nil
+}
+
+type validator struct {
+	db      model.Reader
+	options Options
+	next    http.Handler
+}
+
+func (v *validator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	db := v.db
+	if v.options.Snapshot != nil {
+		db = v.options.Snapshot()
+	}
+
+	endpointModel, err := model.GetModel(db, r.URL.Path, r.Method)
+	if err != nil {
+		// unmodelled requests are passed on, store errors included
+		v.next.ServeHTTP(w, r)
+		return
+	}
+
+	report, err := validate.ValidateRequest(r, endpointModel, db)
+	if err != nil {
+		if v.options.Policy == Reject {
+			status := http.StatusBadRequest
+			if errors.Is(err, validate.ErrBodyTooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			respond(w, status, err.Error())
+			return
+		}
+		v.options.Logger.Printf("request %s %s not validated: %s", r.Method, r.URL.Path, err)
+		v.next.ServeHTTP(w, r)
+		return
+	}
+
+	if v.options.OnReport != nil {
+		v.options.OnReport(r, report)
+	}
+
+	switch v.options.Policy {
+	case Reject:
+		if !report.Valid {
+			respond(w, http.StatusBadRequest, report)
+			return
+		}
+	case Log:
+		if !report.Valid {
+			v.logReport(r, endpointModel, report)
+		}
+	case Annotate:
+		r = r.WithContext(context.WithValue(r.Context(), contextKey{}, report))
+	}
+	v.next.ServeHTTP(w, r)
+}
+
+func (v *validator) logReport(r *http.Request, endpointModel *model.EndpointModel, report *validate.ValidationReport) {
+	errs, err := json.Marshal(report)
+	if err != nil {
+		errs = []byte(err.Error())
+	}
+	v.options.Logger.Printf("invalid request %s %s (model %s %s): %s", r.Method, r.URL.Path, endpointModel.Method, endpointModel.Path, errs)
+}
+
+func respond(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/evgeniron/API-Validator/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	var models []model.Endpoint
+	require.NoError(t, json.Unmarshal([]byte(`[{"path": "/users/{id}", "method": "PUT",
+		"path_params": [{"name": "id", "types": ["Int"], "required": true}],
+		"body": [{"name": "age", "types": ["Int"], "required": true}]}]`), &models))
+	require.NoError(t, model.StoreModels(db, models, ""))
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		target   string
+		body     string
+		status   int
+		reported bool
+		valid    bool
+		logged   string
+	}{
+		{name: "Reject valid", policy: Reject, target: "/users/1", body: `{"age": 42}`, status: http.StatusTeapot, reported: true, valid: true},
+		{name: "Reject invalid", policy: Reject, target: "/users/1", body: `{"age": "old"}`, status: http.StatusBadRequest, reported: true},
+		{name: "Reject malformed", policy: Reject, target: "/users/1", body: `{"age": `, status: http.StatusBadRequest},
+		{name: "Log invalid", policy: Log, target: "/users/abc", body: `{"age": 42}`, status: http.StatusTeapot, reported: true, logged: "invalid request PUT /users/abc (model PUT /users/{id})"},
+		{name: "Log malformed", policy: Log, target: "/users/1", body: `{"age": `, status: http.StatusTeapot, logged: "request PUT /users/1 not validated"},
+		{name: "Annotate invalid", policy: Annotate, target: "/users/1", body: `{}`, status: http.StatusTeapot, reported: true},
+		{name: "Unmodelled", policy: Reject, target: "/orders", body: `{"id": 1}`, status: http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			var reports []*validate.ValidationReport
+			options := Options{
+				Policy:   tt.policy,
+				OnReport: func(r *http.Request, report *validate.ValidationReport) { reports = append(reports, report) },
+				Logger:   log.New(&logs, "", 0),
+				Snapshot: func() model.Reader { return db.Snapshot() },
+			}
+			middleware, err := New(db, options)
+			require.NoError(t, err)
+
+			var annotated *validate.ValidationReport
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				annotated, _ = ReportFromContext(r.Context())
+				// the body is left for the handler
+				var body bytes.Buffer
+				body.ReadFrom(r.Body)
+				assert.Equal(t, tt.body, body.String())
+				w.WriteHeader(http.StatusTeapot)
+			})
+
+			w := httptest.NewRecorder()
+			middleware(next).ServeHTTP(w, httptest.NewRequest(http.MethodPut, tt.target, strings.NewReader(tt.body)))
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+
+			if tt.reported {
+				require.Len(t, reports, 1)
+				assert.Equal(t, tt.valid, reports[0].Valid)
+			} else {
+				assert.Empty(t, reports)
+			}
+			if tt.policy == Annotate && tt.reported {
+				assert.Same(t, reports[0], annotated)
+			} else {
+				assert.Nil(t, annotated)
+			}
+			if tt.status == http.StatusBadRequest {
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			}
+			if tt.logged != "" {
+				assert.Contains(t, logs.String(), tt.logged)
+			} else {
+				assert.Empty(t, logs.String())
+			}
+		})
+	}
+}
+
+func TestMiddlewareRejectReport(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	require.NoError(t, model.StoreModels(db, []model.Endpoint{{
+		Path:        "/search",
+		Method:      http.MethodGet,
+		QueryParams: []model.Field{{Name: "q", Types: []string{"String"}, Required: true}},
+	}}, ""))
+
+	middleware, err := New(db, DefaultOptions())
+	require.NoError(t, err)
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid request passed on")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var report validate.ValidationReport
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	assert.False(t, report.Valid)
+	assert.Equal(t, []validate.ValidationError{{FieldName: "q", Path: "/q", ErrorType: validate.ErrMissingRequiredField}}, report.QueryParams)
+}
+
+func TestMiddlewareUnknownPolicy(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+
+	_, err = New(db, Options{Policy: "drop"})
+	assert.EqualError(t, err, `unknown policy "drop"`)
+
+	_, err = New(db, Options{})
+	assert.NoError(t, err)
+}
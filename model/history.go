@@ -0,0 +1,357 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/evgeniron/API-Validator/store"
+)
+
+/*
+Every model written to the store is also appended to the history of its endpoint as a numbered revision.
+The history is kept under its own key next to the current model and is written in the same batch, so the
+current model is always the latest revision. Deleting a model keeps its history, so it can be rolled back.
+A history keeps the latest maxRevisions revisions, older revisions are dropped and can't be rolled back to.
+*/
+
+// writeMu serializes read-modify-write updates of models and their history,
+// so concurrent uploads can't lose revisions
+var writeMu sync.Mutex
+
+// now is replaced in tests
+var now = time.Now
+
+// maxRevisions is the number of revisions kept per endpoint, it's replaced in tests
+var maxRevisions = 100
+
+type Revision struct {
+	Number    int            `json:"number"`
+	Timestamp time.Time      `json:"timestamp"`
+	Author    string         `json:"author"`
+	Model     *EndpointModel `json:"model"`
+}
+
+type History struct {
+	Path      string     `json:"path"`
+	Method    string     `json:"method"`
+	Revisions []Revision `json:"revisions"`
+}
+
+// RevisionNotFoundError reports a revision number missing from the endpoint history
+type RevisionNotFoundError struct {
+	Revision int
+}
+
+func (e *RevisionNotFoundError) Error() string {
+	return fmt.Sprintf("revision not found: %d", e.Revision)
+}
+
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Change describes a difference between two revisions. Field is the JSON pointer
+// of the field inside its section, From and To are its *FieldModel. Changes of the
+// "content_type" section have no Field, From and To are the content types. Changes
+// of the "rules" section name the rule in Field, From and To are its *Rule, and
+// changes of the "requirements" section name the section the *Requirements apply to.
+type Change struct {
+	Section string      `json:"section"`
+	Field   string      `json:"field"`
+	Change  string      `json:"change"`
+	From    interface{} `json:"from,omitempty"`
+	To      interface{} `json:"to,omitempty"`
+}
+
+func historyKey(path, method string) string {
+	return "history:" + generateKey(path, method)
+}
+
+// GetHistory returns every revision of the model stored for the exact path and method
+func GetHistory(db Reader, path, method string) (*History, error) {
+	record, err := db.Get(historyKey(path, method))
+	if err != nil {
+		return nil, err
+	}
+
+	history, ok := record.(*History)
+	if !ok {
+		return nil, fmt.Errorf("incorrect type - expecting History type")
+	}
+	return history, nil
+}
+
+// GetRevision returns a single revision from the endpoint history
+func GetRevision(db Reader, path, method string, number int) (*Revision, error) {
+	history, err := GetHistory(db, path, method)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range history.Revisions {
+		if history.Revisions[i].Number == number {
+			return &history.Revisions[i], nil
+		}
+	}
+	return nil, &RevisionNotFoundError{Revision: number}
+}
+
+// Rollback reinstates the model of an older revision as the current model,
+// recording it as a new revision
+func Rollback(db Store, path, method string, number int, author string) (*Revision, error) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	revision, err := GetRevision(db, path, method, number)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := withRevisions(db, []*EndpointModel{revision.Model.clone()}, author)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.InsertBatch(records); err != nil {
+		return nil, err
+	}
+
+	history := records[historyKey(path, method)].(*History)
+	return &history.Revisions[len(history.Revisions)-1], nil
+}
+
+// Diff lists the changes between two revisions of the endpoint model
+func Diff(db Reader, path, method string, from, to int) ([]Change, error) {
+	fromRevision, err := GetRevision(db, path, method, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRevision, err := GetRevision(db, path, method, to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffContentType(fromRevision.Model.ContentType, toRevision.Model.ContentType)
+	type section struct {
+		name     string
+		from, to map[string]FieldModel
+	}
+	sections := []section{
+		{"path_params", fromRevision.Model.PathParams, toRevision.Model.PathParams},
+		{"query_params", fromRevision.Model.QueryParams, toRevision.Model.QueryParams},
+		{"headers", fromRevision.Model.Headers, toRevision.Model.Headers},
+		{"body", fromRevision.Model.Body, toRevision.Model.Body},
+	}
+	for _, status := range responseStatuses(fromRevision.Model.Responses, toRevision.Model.Responses) {
+		fromResponse, toResponse := fromRevision.Model.Responses[status], toRevision.Model.Responses[status]
+		name := fmt.Sprintf("responses/%d/", status)
+		sections = append(sections,
+			section{name + "headers", fromResponse.Headers, toResponse.Headers},
+			section{name + "body", fromResponse.Body, toResponse.Body})
+	}
+	for _, section := range sections {
+		changes = append(changes, diffFields(section.name, "", section.from, section.to)...)
+	}
+	changes = append(changes, diffRules(fromRevision.Model.Rules, toRevision.Model.Rules)...)
+	changes = append(changes, diffRequirements(fromRevision.Model.Requirements, toRevision.Model.Requirements)...)
+	return changes, nil
+}
+
+func diffContentType(from, to string) []Change {
+	change := Change{Section: "content_type"}
+	switch {
+	case from == to:
+		return []Change{}
+	case from == "":
+		change.Change, change.To = ChangeAdded, to
+	case to == "":
+		change.Change, change.From = ChangeRemoved, from
+	default:
+		change.Change, change.From, change.To = ChangeChanged, from, to
+	}
+	return []Change{change}
+}
+
+// diffRules compares the rules by name, unnamed rules and rules whose name is
+// taken by an earlier rule by index
+func diffRules(from, to []Rule) []Change {
+	fromRules, toRules := keyedRules(from), keyedRules(to)
+	keys := make([]string, 0, len(fromRules)+len(toRules))
+	for key := range fromRules {
+		keys = append(keys, key)
+	}
+	for key := range toRules {
+		if _, ok := fromRules[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []Change
+	for _, key := range keys {
+		fromRule, inFrom := fromRules[key]
+		toRule, inTo := toRules[key]
+
+		switch {
+		case !inFrom:
+			changes = append(changes, Change{Section: "rules", Field: "/" + key, Change: ChangeAdded, To: toRule})
+		case !inTo:
+			changes = append(changes, Change{Section: "rules", Field: "/" + key, Change: ChangeRemoved, From: fromRule})
+		case *fromRule != *toRule:
+			changes = append(changes, Change{Section: "rules", Field: "/" + key, Change: ChangeChanged, From: fromRule, To: toRule})
+		}
+	}
+	return changes
+}
+
+func keyedRules(rules []Rule) map[string]*Rule {
+	keyed := make(map[string]*Rule, len(rules))
+	for i, rule := range rules {
+		rule := rule
+		key := rule.Name
+		if _, taken := keyed[key]; key == "" || taken {
+			key = strconv.Itoa(i)
+		}
+		keyed[key] = &rule
+	}
+	return keyed
+}
+
+// diffRequirements compares the requirements of each section, the requirements of
+// object properties are compared with their fields
+func diffRequirements(from, to map[string]Requirements) []Change {
+	sections := make([]string, 0, len(from)+len(to))
+	for section := range from {
+		sections = append(sections, section)
+	}
+	for section := range to {
+		if _, ok := from[section]; !ok {
+			sections = append(sections, section)
+		}
+	}
+	sort.Strings(sections)
+
+	var changes []Change
+	for _, section := range sections {
+		fromRequirements, inFrom := from[section]
+		toRequirements, inTo := to[section]
+
+		switch {
+		case !inFrom:
+			changes = append(changes, Change{Section: "requirements", Field: "/" + section, Change: ChangeAdded, To: &toRequirements})
+		case !inTo:
+			changes = append(changes, Change{Section: "requirements", Field: "/" + section, Change: ChangeRemoved, From: &fromRequirements})
+		case !reflect.DeepEqual(fromRequirements, toRequirements):
+			changes = append(changes, Change{Section: "requirements", Field: "/" + section, Change: ChangeChanged, From: &fromRequirements, To: &toRequirements})
+		}
+	}
+	return changes
+}
+
+// responseStatuses returns the status codes of both response sets, sorted
+func responseStatuses(from, to map[int]ResponseModel) []int {
+	statuses := make([]int, 0, len(from)+len(to))
+	for status := range from {
+		statuses = append(statuses, status)
+	}
+	for status := range to {
+		if _, ok := from[status]; !ok {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Ints(statuses)
+	return statuses
+}
+
+func diffFields(section, parent string, from, to map[string]FieldModel) []Change {
+	names := make(map[string]struct{}, len(from)+len(to))
+	for name := range from {
+		names[name] = struct{}{}
+	}
+	for name := range to {
+		names[name] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []Change
+	for _, name := range sorted {
+		pointer := parent + "/" + name
+		fromField, inFrom := from[name]
+		toField, inTo := to[name]
+
+		switch {
+		case !inFrom:
+			changes = append(changes, Change{Section: section, Field: pointer, Change: ChangeAdded, To: &toField})
+		case !inTo:
+			changes = append(changes, Change{Section: section, Field: pointer, Change: ChangeRemoved, From: &fromField})
+		default:
+			fromShallow, toShallow := fromField, toField
+			fromShallow.Properties, toShallow.Properties = nil, nil
+			if !reflect.DeepEqual(fromShallow, toShallow) {
+				changes = append(changes, Change{Section: section, Field: pointer, Change: ChangeChanged, From: &fromField, To: &toField})
+			}
+			changes = append(changes, diffFields(section, pointer, fromField.Properties, toField.Properties)...)
+		}
+	}
+	return changes
+}
+
+// withRevisions returns the store records for the models together with their
+// histories, each extended with a new revision
+func withRevisions(db Reader, endpointModels []*EndpointModel, author string) (map[string]interface{}, error) {
+	timestamp := now().UTC()
+	records := make(map[string]interface{}, 2*len(endpointModels))
+	for _, endpointModel := range endpointModels {
+		key := historyKey(endpointModel.Path, endpointModel.Method)
+
+		// a batch may carry the same endpoint more than once
+		history, ok := records[key].(*History)
+		if !ok {
+			stored, err := GetHistory(db, endpointModel.Path, endpointModel.Method)
+			var e *store.RecordNotFoundError
+			if err != nil && !errors.As(err, &e) {
+				return nil, err
+			}
+			history = &History{Path: endpointModel.Path, Method: endpointModel.Method}
+			if stored != nil {
+				history.Revisions = stored.Revisions
+			}
+		}
+
+		number := 1
+		if n := len(history.Revisions); n > 0 {
+			number = history.Revisions[n-1].Number + 1
+		}
+
+		// copy the kept revisions, the stored history belongs to a published snapshot
+		kept := history.Revisions
+		if len(kept) >= maxRevisions {
+			kept = kept[len(kept)-maxRevisions+1:]
+		}
+		revisions := make([]Revision, len(kept), len(kept)+1)
+		copy(revisions, kept)
+		history = &History{Path: history.Path, Method: history.Method, Revisions: append(revisions, Revision{
+			Number:    number,
+			Timestamp: timestamp,
+			Author:    author,
+			Model:     endpointModel,
+		})}
+
+		records[key] = history
+		records[generateKey(endpointModel.Path, endpointModel.Method)] = endpointModel
+	}
+	return records, nil
+}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	timestamp := time.Date(2023, 2, 11, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return timestamp }
+	defer func() { now = time.Now }()
+
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+
+	first := Endpoint{
+		Path:   "/users/create",
+		Method: "POST",
+		Body: []Field{
+			{Name: "firstName", Types: []string{"String"}, Required: true},
+			{Name: "address", Types: []string{"Object"}, Properties: []Field{{Name: "city", Types: []string{"String"}}}},
+		},
+	}
+	second := Endpoint{
+		Path:        "/users/create",
+		Method:      "POST",
+		ContentType: "application/json",
+		Body: []Field{
+			{Name: "firstName", Types: []string{"Int"}, Required: true},
+			{Name: "address", Types: []string{"Object"}, Properties: []Field{{Name: "zip", Types: []string{"String"}}}},
+			{Name: "email", Types: []string{"Email"}},
+		},
+		Responses: map[int]Response{201: {Body: []Field{{Name: "id", Types: []string{"Int"}, Required: true}}}},
+	}
+	require.NoError(t, StoreModel(db, &first, "alice"))
+	require.NoError(t, StoreModels(db, []Endpoint{second}, "bob"))
+
+	history, err := GetHistory(db, "/users/create", "POST")
+	require.NoError(t, err)
+	require.Len(t, history.Revisions, 2)
+	require.Equal(t, 1, history.Revisions[0].Number)
+	require.Equal(t, "alice", history.Revisions[0].Author)
+	require.Equal(t, timestamp, history.Revisions[0].Timestamp)
+	require.Equal(t, 2, history.Revisions[1].Number)
+	require.Equal(t, "bob", history.Revisions[1].Author)
+
+	changes, err := Diff(db, "/users/create", "POST", 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{Section: "content_type", Change: ChangeAdded, To: "application/json"},
+		{Section: "body", Field: "/address/city", Change: ChangeRemoved, From: &FieldModel{Types: []string{"String"}}},
+		{Section: "body", Field: "/address/zip", Change: ChangeAdded, To: &FieldModel{Types: []string{"String"}}},
+		{Section: "body", Field: "/email", Change: ChangeAdded, To: &FieldModel{Types: []string{"Email"}}},
+		{
+			Section: "body",
+			Field:   "/firstName",
+			Change:  ChangeChanged,
+			From:    &FieldModel{Types: []string{"String"}, Required: true},
+			To:      &FieldModel{Types: []string{"Int"}, Required: true},
+		},
+		{Section: "responses/201/body", Field: "/id", Change: ChangeAdded, To: &FieldModel{Types: []string{"Int"}, Required: true}},
+	}, changes)
+
+	_, err = Diff(db, "/users/create", "POST", 1, 5)
+	var revisionErr *RevisionNotFoundError
+	require.ErrorAs(t, err, &revisionErr)
+
+	// a deleted model keeps its history and can be rolled back
+	require.NoError(t, DeleteModel(db, "/users/create", "POST"))
+
+	revision, err := Rollback(db, "/users/create", "POST", 1, "carol")
+	require.NoError(t, err)
+	require.Equal(t, 3, revision.Number)
+	require.Equal(t, "carol", revision.Author)
+
+	current, err := GetModel(db, "/users/create", "POST")
+	require.NoError(t, err)
+	require.Equal(t, history.Revisions[0].Model, current)
+
+	changes, err = Diff(db, "/users/create", "POST", 1, 3)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	_, err = Rollback(db, "/users/delete", "POST", 1, "carol")
+	var notFoundErr *store.RecordNotFoundError
+	require.ErrorAs(t, err, &notFoundErr)
+}
+
+func TestDiffRules(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+
+	first := Endpoint{
+		Path:   "/orders",
+		Method: "POST",
+		Rules: []Rule{
+			{Name: "dates", Expr: "body.from <= body.to"},
+			{Name: "limit", Expr: "body.count < 10"},
+			{Expr: "body.count > 0"},
+		},
+	}
+	second := Endpoint{
+		Path:   "/orders",
+		Method: "POST",
+		Rules: []Rule{
+			{Name: "dates", Expr: "body.from < body.to", Message: "from must be before to"},
+			{Expr: "body.count > 0"},
+			{Name: "currency", Expr: "present(body.currency)"},
+		},
+	}
+	require.NoError(t, StoreModel(db, &first, "alice"))
+	require.NoError(t, StoreModel(db, &second, "bob"))
+
+	changes, err := Diff(db, "/orders", "POST", 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{Section: "rules", Field: "/1", Change: ChangeAdded, To: &Rule{Expr: "body.count > 0"}},
+		{Section: "rules", Field: "/2", Change: ChangeRemoved, From: &Rule{Expr: "body.count > 0"}},
+		{Section: "rules", Field: "/currency", Change: ChangeAdded, To: &Rule{Name: "currency", Expr: "present(body.currency)"}},
+		{
+			Section: "rules",
+			Field:   "/dates",
+			Change:  ChangeChanged,
+			From:    &Rule{Name: "dates", Expr: "body.from <= body.to"},
+			To:      &Rule{Name: "dates", Expr: "body.from < body.to", Message: "from must be before to"},
+		},
+		{Section: "rules", Field: "/limit", Change: ChangeRemoved, From: &Rule{Name: "limit", Expr: "body.count < 10"}},
+	}, changes)
+}
+
+func TestDiffRequirements(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+
+	first := Endpoint{
+		Path:   "/contacts",
+		Method: "POST",
+		Body:   []Field{{Name: "email", Types: []string{"Email"}}, {Name: "phone", Types: []string{"String"}}},
+		Requirements: map[string]Requirements{
+			"body":    {OneOfRequired: [][]string{{"email", "phone"}}},
+			"headers": {MutuallyExclusive: [][]string{{"X-Token", "Authorization"}}},
+		},
+	}
+	second := first
+	second.Requirements = map[string]Requirements{
+		"body":         {MutuallyExclusive: [][]string{{"email", "phone"}}},
+		"query_params": {DependentRequired: map[string][]string{"page": {"limit"}}},
+	}
+	require.NoError(t, StoreModel(db, &first, "alice"))
+	require.NoError(t, StoreModel(db, &second, "bob"))
+
+	changes, err := Diff(db, "/contacts", "POST", 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{
+			Section: "requirements",
+			Field:   "/body",
+			Change:  ChangeChanged,
+			From:    &Requirements{OneOfRequired: [][]string{{"email", "phone"}}},
+			To:      &Requirements{MutuallyExclusive: [][]string{{"email", "phone"}}},
+		},
+		{Section: "requirements", Field: "/headers", Change: ChangeRemoved, From: &Requirements{MutuallyExclusive: [][]string{{"X-Token", "Authorization"}}}},
+		{Section: "requirements", Field: "/query_params", Change: ChangeAdded, To: &Requirements{DependentRequired: map[string][]string{"page": {"limit"}}}},
+	}, changes)
+}
+
+func TestHistoryRetention(t *testing.T) {
+	maxRevisions = 3
+	defer func() { maxRevisions = 100 }()
+
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, StoreModel(db, &Endpoint{Path: "/users", Method: "GET"}, "alice"))
+	}
+
+	history, err := GetHistory(db, "/users", "GET")
+	require.NoError(t, err)
+	require.Len(t, history.Revisions, 3)
+	require.Equal(t, 3, history.Revisions[0].Number)
+	require.Equal(t, 5, history.Revisions[2].Number)
+
+	// dropped revisions are gone, the numbering goes on
+	_, err = Rollback(db, "/users", "GET", 2, "bob")
+	var revisionErr *RevisionNotFoundError
+	require.ErrorAs(t, err, &revisionErr)
+
+	revision, err := Rollback(db, "/users", "GET", 3, "bob")
+	require.NoError(t, err)
+	require.Equal(t, 6, revision.Number)
+}
@@ -0,0 +1,310 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Problem describes a part of a model that can't be validated as written.
+// Location names the model and field, e.g. "POST /users body/address/city", and
+// Index is the position of the model in the upload.
+type Problem struct {
+	Index    int    `json:"index"`
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+var methods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+type linter struct {
+	// knownType reports whether fields of the type can be validated
+	knownType func(name string) bool
+	index     int
+	problems  []Problem
+}
+
+func (l *linter) problem(location string, format string, args ...interface{}) {
+	l.problems = append(l.problems, Problem{Index: l.index, Location: location, Message: fmt.Sprintf(format, args...)})
+}
+
+// Lint checks the models for unknown types, duplicate fields, empty paths,
+// invalid HTTP methods and requirements on unknown fields. knownType reports
+// whether a type name can be validated.
+func Lint(models []Endpoint, knownType func(name string) bool) []Problem {
+	l := &linter{knownType: knownType}
+	seen := make(map[string]int, len(models))
+	for i, endpoint := range models {
+		l.index = i
+		location := strings.TrimSpace(endpoint.Method + " " + endpoint.Path)
+
+		if endpoint.Path == "" {
+			l.problem(location, "empty path")
+		} else if !strings.HasPrefix(endpoint.Path, "/") {
+			l.problem(location, "path %q does not start with /", endpoint.Path)
+		}
+		if !methods[endpoint.Method] {
+			l.problem(location, "invalid HTTP method %q", endpoint.Method)
+		}
+
+		key := generateKey(endpoint.Path, endpoint.Method)
+		if first, exists := seen[key]; exists {
+			l.problem(location, "duplicate model, first defined at index %d", first)
+		} else {
+			seen[key] = i
+		}
+
+		templateParams := make(map[string]bool)
+		for _, segment := range splitPath(endpoint.Path) {
+			if name, ok := paramName(segment); ok {
+				templateParams[name] = true
+			}
+		}
+		for _, field := range endpoint.PathParams {
+			if field.Name != "" && !templateParams[field.Name] {
+				l.problem(location+" path_params/"+field.Name, "path parameter is not in the path template")
+			}
+		}
+
+		l.fields(location+" path_params", endpoint.PathParams)
+		l.fields(location+" query_params", endpoint.QueryParams)
+		l.fields(location+" headers", endpoint.Headers)
+		l.fields(location+" body", endpoint.Body)
+		if endpoint.ContentType != "" {
+			if _, _, err := mime.ParseMediaType(endpoint.ContentType); err != nil {
+				l.problem(location+" content_type", "invalid media type %q", endpoint.ContentType)
+			}
+		}
+
+		sections := map[string][]Field{
+			"path_params":  endpoint.PathParams,
+			"query_params": endpoint.QueryParams,
+			"headers":      endpoint.Headers,
+			"body":         endpoint.Body,
+		}
+		for _, section := range sortedSections(endpoint.Requirements) {
+			fields, ok := sections[section]
+			if !ok {
+				l.problem(location+" requirements/"+section, "unknown section %q", section)
+				continue
+			}
+			requirements := endpoint.Requirements[section]
+			l.requirements(location+" requirements/"+section, &requirements, fields)
+		}
+
+		for _, status := range sortedStatuses(endpoint.Responses) {
+			responseLocation := fmt.Sprintf("%s responses/%d", location, status)
+			if status < 100 || status > 599 {
+				l.problem(responseLocation, "invalid status code %d", status)
+			}
+			l.fields(responseLocation+" headers", endpoint.Responses[status].Headers)
+			l.fields(responseLocation+" body", endpoint.Responses[status].Body)
+		}
+	}
+	return l.problems
+}
+
+func sortedStatuses(responses map[int]Response) []int {
+	statuses := make([]int, 0, len(responses))
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	return statuses
+}
+
+func sortedSections(requirements map[string]Requirements) []string {
+	sections := make([]string, 0, len(requirements))
+	for section := range requirements {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	return sections
+}
+
+// LintPatches checks the fields added or replaced by the patches
+func LintPatches(patches []FieldPatch, knownType func(name string) bool) []Problem {
+	l := &linter{knownType: knownType}
+	for i, patch := range patches {
+		if patch.Field == nil {
+			continue
+		}
+		l.index = i
+
+		field := *patch.Field
+		if patch.Name != "" {
+			field.Name = patch.Name
+		}
+		l.field(patch.Section, field)
+	}
+	return l.problems
+}
+
+func (l *linter) fields(location string, fields []Field) {
+	names := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if names[field.Name] {
+			l.problem(location+"/"+field.Name, "duplicate field name")
+		}
+		names[field.Name] = true
+		l.field(location, field)
+	}
+}
+
+func (l *linter) field(parent string, field Field) {
+	location := parent + "/" + field.Name
+	if field.Name == "" {
+		l.problem(location, "empty field name")
+	}
+	l.value(location, field)
+}
+
+// value checks the types and constraints of a field, list items and combinator
+// branches have no name
+func (l *linter) value(location string, field Field) {
+	for _, typeName := range field.Types {
+		if !l.knownType(typeName) {
+			l.problem(location, "unknown type %q", typeName)
+		}
+	}
+
+	if field.Constraints != nil {
+		l.constraints(location, field.Constraints)
+	}
+
+	if field.Properties != nil {
+		l.fields(location, field.Properties)
+	}
+	if field.Requirements != nil {
+		l.requirements(location+"/requirements", field.Requirements, field.Properties)
+	}
+	if field.Items != nil {
+		l.value(location+"/items", *field.Items)
+	}
+
+	l.branches(location+"/all_of", field.AllOf)
+	l.branches(location+"/any_of", field.AnyOf)
+	l.branches(location+"/one_of", field.OneOf)
+	if field.Not != nil {
+		l.value(location+"/not", *field.Not)
+	}
+}
+
+func (l *linter) branches(location string, branches []Field) {
+	for i, branch := range branches {
+		l.value(fmt.Sprintf("%s/%d", location, i), branch)
+	}
+}
+
+// requirements checks that the requirements name the fields they apply to
+func (l *linter) requirements(location string, requirements *Requirements, fields []Field) {
+	names := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		names[field.Name] = true
+	}
+	known := func(name string) {
+		if !names[name] {
+			l.problem(location, "unknown field %q", name)
+		}
+	}
+
+	triggers := make([]string, 0, len(requirements.DependentRequired))
+	for trigger := range requirements.DependentRequired {
+		triggers = append(triggers, trigger)
+	}
+	sort.Strings(triggers)
+	for _, trigger := range triggers {
+		known(trigger)
+		for _, name := range requirements.DependentRequired[trigger] {
+			known(name)
+		}
+	}
+
+	for _, requiredIf := range requirements.RequiredIf {
+		known(requiredIf.Field)
+		if len(requiredIf.Values) == 0 {
+			l.problem(location, "required_if on %q has no values", requiredIf.Field)
+		}
+		for _, name := range requiredIf.Required {
+			known(name)
+		}
+	}
+
+	groups := append(append([][]string{}, requirements.OneOfRequired...), requirements.MutuallyExclusive...)
+	for _, group := range groups {
+		if len(group) < 2 {
+			l.problem(location, "group %v has less than 2 fields", group)
+		}
+		for _, name := range group {
+			known(name)
+		}
+	}
+}
+
+func (l *linter) constraints(location string, constraints *Constraints) {
+	if constraints.Pattern != "" {
+		if _, err := regexp.Compile(constraints.Pattern); err != nil {
+			l.problem(location, "invalid pattern: %v", err)
+		}
+	}
+
+	numbers := []struct {
+		name  string
+		value json.Number
+	}{
+		{"minimum", constraints.Minimum},
+		{"maximum", constraints.Maximum},
+		{"exclusive_minimum", constraints.ExclusiveMinimum},
+		{"exclusive_maximum", constraints.ExclusiveMaximum},
+		{"multiple_of", constraints.MultipleOf},
+	}
+	for _, n := range numbers {
+		if n.value != "" && !NumberPattern.MatchString(n.value.String()) {
+			l.problem(location, "%s %q is not a number", n.name, n.value)
+		}
+	}
+
+	if constraints.MultipleOf != "" {
+		if multiple, err := constraints.MultipleOf.Float64(); err == nil && multiple <= 0 {
+			l.problem(location, "multiple_of must be greater than 0")
+		}
+	}
+
+	bounds := []struct {
+		name  string
+		bound *int
+	}{
+		{"min_length", constraints.MinLength},
+		{"max_length", constraints.MaxLength},
+		{"min_items", constraints.MinItems},
+		{"max_items", constraints.MaxItems},
+	}
+	for _, b := range bounds {
+		if b.bound != nil && *b.bound < 0 {
+			l.problem(location, "%s must not be negative", b.name)
+		}
+	}
+	if constraints.MaxSize != nil && *constraints.MaxSize < 0 {
+		l.problem(location, "max_size must not be negative")
+	}
+
+	for _, mediaType := range constraints.MediaTypes {
+		if _, _, err := mime.ParseMediaType(mediaType); err != nil {
+			l.problem(location, "invalid media type %q", mediaType)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func knownType(name string) bool {
+	return name == "String" || name == "Int" || name == "Object" || name == "List"
+}
+
+func TestLint(t *testing.T) {
+	negative := -1
+	negativeSize := int64(-1)
+
+	tests := []struct {
+		name     string
+		models   []Endpoint
+		expected []Problem
+	}{
+		{
+			name: "Valid models",
+			models: []Endpoint{
+				{
+					Path:       "/users/{id}",
+					Method:     "GET",
+					PathParams: []Field{{Name: "id", Types: []string{"Int"}, Required: true}},
+					Body: []Field{
+						{Name: "address", Types: []string{"Object"}, Properties: []Field{{Name: "city", Types: []string{"String"}}}},
+						{Name: "tags", Types: []string{"List"}, Items: &Field{Types: []string{"String"}}},
+					},
+				},
+				{Path: "/users/{id}", Method: "DELETE"},
+			},
+		},
+		{
+			name: "Unknown types",
+			models: []Endpoint{{
+				Path:    "/users",
+				Method:  "GET",
+				Headers: []Field{{Name: "Authorization", Types: []string{"String", "Auth-Token"}}},
+				Body: []Field{
+					{Name: "tags", Types: []string{"List"}, Items: &Field{Types: []string{"Tag"}}},
+					{Name: "id", AnyOf: []Field{{Types: []string{"Int"}}, {Types: []string{"UUID"}}}, Not: &Field{Types: []string{"Nil"}}},
+				},
+			}},
+			expected: []Problem{
+				{Location: "GET /users headers/Authorization", Message: `unknown type "Auth-Token"`},
+				{Location: "GET /users body/tags/items", Message: `unknown type "Tag"`},
+				{Location: "GET /users body/id/any_of/1", Message: `unknown type "UUID"`},
+				{Location: "GET /users body/id/not", Message: `unknown type "Nil"`},
+			},
+		},
+		{
+			name: "Duplicate fields",
+			models: []Endpoint{{
+				Path:        "/users",
+				Method:      "GET",
+				QueryParams: []Field{{Name: "id", Types: []string{"Int"}}, {Name: "id", Types: []string{"String"}}},
+				Body: []Field{{Name: "address", Types: []string{"Object"}, Properties: []Field{
+					{Name: "city", Types: []string{"String"}},
+					{Name: "city", Types: []string{"String"}},
+				}}},
+			}},
+			expected: []Problem{
+				{Location: "GET /users query_params/id", Message: "duplicate field name"},
+				{Location: "GET /users body/address/city", Message: "duplicate field name"},
+			},
+		},
+		{
+			name: "Invalid paths and methods",
+			models: []Endpoint{
+				{Path: "", Method: "GET"},
+				{Path: "users", Method: "get"},
+				{Path: "/users", Method: "FETCH"},
+				{Path: "/users", Method: "FETCH"},
+			},
+			expected: []Problem{
+				{Index: 0, Location: "GET", Message: "empty path"},
+				{Index: 1, Location: "get users", Message: `path "users" does not start with /`},
+				{Index: 1, Location: "get users", Message: `invalid HTTP method "get"`},
+				{Index: 2, Location: "FETCH /users", Message: `invalid HTTP method "FETCH"`},
+				{Index: 3, Location: "FETCH /users", Message: `invalid HTTP method "FETCH"`},
+				{Index: 3, Location: "FETCH /users", Message: "duplicate model, first defined at index 2"},
+			},
+		},
+		{
+			name: "Path params and constraints",
+			models: []Endpoint{{
+				Path:       "/users/{id}",
+				Method:     "GET",
+				PathParams: []Field{{Name: "user_id", Types: []string{"Int"}}},
+				Body: []Field{
+					{Name: "", Types: []string{"String"}},
+					{Name: "name", Types: []string{"String"}, Constraints: &Constraints{Pattern: "([a-z]", MinLength: &negative}},
+					{Name: "count", Types: []string{"Int"}, Constraints: &Constraints{MultipleOf: "0"}},
+					{Name: "age", Types: []string{"Int"}, Constraints: &Constraints{Minimum: "abc", ExclusiveMaximum: "0x1F"}},
+				},
+			}},
+			expected: []Problem{
+				{Location: "GET /users/{id} path_params/user_id", Message: "path parameter is not in the path template"},
+				{Location: "GET /users/{id} body/", Message: "empty field name"},
+				{Location: "GET /users/{id} body/name", Message: "invalid pattern: error parsing regexp: missing closing ): `([a-z]`"},
+				{Location: "GET /users/{id} body/name", Message: "min_length must not be negative"},
+				{Location: "GET /users/{id} body/count", Message: "multiple_of must be greater than 0"},
+				{Location: "GET /users/{id} body/age", Message: `minimum "abc" is not a number`},
+				{Location: "GET /users/{id} body/age", Message: `exclusive_maximum "0x1F" is not a number`},
+			},
+		},
+		{
+			name: "Requirements",
+			models: []Endpoint{{
+				Path:        "/contacts",
+				Method:      "POST",
+				QueryParams: []Field{{Name: "notify", Types: []string{"String"}}},
+				Body: []Field{
+					{Name: "email", Types: []string{"String"}},
+					{Name: "phone", Types: []string{"String"}},
+					{Name: "address", Types: []string{"Object"}, Properties: []Field{{Name: "city", Types: []string{"String"}}},
+						Requirements: &Requirements{DependentRequired: map[string][]string{"city": {"zip"}}}},
+				},
+				Requirements: map[string]Requirements{
+					"body": {
+						RequiredIf:        []RequiredIf{{Field: "contact_method", Required: []string{"phone"}}},
+						OneOfRequired:     [][]string{{"email", "phone"}},
+						MutuallyExclusive: [][]string{{"email"}},
+					},
+					"query_params": {DependentRequired: map[string][]string{"notify": {"email", "notify"}}},
+					"cookies":      {},
+				},
+			}},
+			expected: []Problem{
+				{Location: "POST /contacts body/address/requirements", Message: `unknown field "zip"`},
+				{Location: "POST /contacts requirements/body", Message: `unknown field "contact_method"`},
+				{Location: "POST /contacts requirements/body", Message: `required_if on "contact_method" has no values`},
+				{Location: "POST /contacts requirements/body", Message: "group [email] has less than 2 fields"},
+				{Location: "POST /contacts requirements/cookies", Message: `unknown section "cookies"`},
+				{Location: "POST /contacts requirements/query_params", Message: `unknown field "email"`},
+			},
+		},
+		{
+			name: "Content types",
+			models: []Endpoint{{
+				Path:        "/uploads",
+				Method:      "POST",
+				ContentType: "multipart/",
+				Body: []Field{{Name: "file", Types: []string{"String"}, Constraints: &Constraints{
+					MaxSize:    &negativeSize,
+					MediaTypes: []string{"image/*", "image/png;;"},
+				}}},
+			}},
+			expected: []Problem{
+				{Location: "POST /uploads body/file", Message: "max_size must not be negative"},
+				{Location: "POST /uploads body/file", Message: `invalid media type "image/png;;"`},
+				{Location: "POST /uploads content_type", Message: `invalid media type "multipart/"`},
+			},
+		},
+		{
+			name: "Responses",
+			models: []Endpoint{{
+				Path:   "/users",
+				Method: "POST",
+				Responses: map[int]Response{
+					201: {Headers: []Field{{Name: "Location", Types: []string{"String"}}}, Body: []Field{{Name: "id", Types: []string{"UUID"}}}},
+					99:  {},
+					400: {Body: []Field{{Name: "error", Types: []string{"String"}}, {Name: "error", Types: []string{"String"}}}},
+				},
+			}},
+			expected: []Problem{
+				{Location: "POST /users responses/99", Message: "invalid status code 99"},
+				{Location: "POST /users responses/201 body/id", Message: `unknown type "UUID"`},
+				{Location: "POST /users responses/400 body/error", Message: "duplicate field name"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, Lint(tt.models, knownType))
+		})
+	}
+}
+
+func TestLintPatches(t *testing.T) {
+	problems := LintPatches([]FieldPatch{
+		{Op: PatchRemove, Section: "body", Name: "name"},
+		{Op: PatchAdd, Section: "headers", Field: &Field{Name: "Authorization", Types: []string{"Auth-Token"}}},
+	}, knownType)
+	require.Equal(t, []Problem{{Index: 1, Location: "headers/Authorization", Message: `unknown type "Auth-Token"`}}, problems)
+}
@@ -1,41 +1,168 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/evgeniron/API-Validator/utils"
 )
 
+// RecordKinds are the kinds of records the model package keeps in a store, by
+// kind name, for stores that persist records by kind, see store.NewFileDB
+var RecordKinds = map[string]interface{}{
+	"endpoint_model":  &EndpointModel{},
+	"model_history":   &History{},
+	"type_definition": &TypeDefinition{},
+}
+
+// Reader is the read side of the store. Reading models through a single store
+// snapshot gives a consistent view even while models are being uploaded.
+type Reader interface {
+	Get(model string) (interface{}, error)
+	List() ([]interface{}, error)
+}
+
 type Store interface {
+	Reader
 	Insert(key string, model interface{}) error
-	Get(model string) (interface{}, error)
+	InsertBatch(models map[string]interface{}) error
+	Delete(key string) error
 }
 type Field struct {
-	Name     string   `json:"name"`
-	Types    []string `json:"types"`
-	Required bool     `json:"required"`
+	Name        string       `json:"name"`
+	Types       []string     `json:"types"`
+	Required    bool         `json:"required"`
+	Properties  []Field      `json:"properties,omitempty"`
+	Items       *Field       `json:"items,omitempty"`
+	Constraints *Constraints `json:"constraints,omitempty"`
+	// Requirements apply to the Properties
+	Requirements *Requirements `json:"requirements,omitempty"`
+
+	// The combinators combine unnamed field models, the value must match all the
+	// AllOf branches, at least one AnyOf branch, exactly one OneOf branch and
+	// must not match Not. Types keep their meaning, every type must match.
+	AllOf []Field `json:"all_of,omitempty"`
+	AnyOf []Field `json:"any_of,omitempty"`
+	OneOf []Field `json:"one_of,omitempty"`
+	Not   *Field  `json:"not,omitempty"`
+}
+
+// Constraints restrict the value of a field beyond its types. Numeric bounds apply
+// to numbers, lengths and pattern to strings and the item bounds to lists, a
+// constraint is ignored for values it doesn't apply to. Const can't be null.
+type Constraints struct {
+	Minimum          json.Number   `json:"minimum,omitempty"`
+	Maximum          json.Number   `json:"maximum,omitempty"`
+	ExclusiveMinimum json.Number   `json:"exclusive_minimum,omitempty"`
+	ExclusiveMaximum json.Number   `json:"exclusive_maximum,omitempty"`
+	MultipleOf       json.Number   `json:"multiple_of,omitempty"`
+	MinLength        *int          `json:"min_length,omitempty"`
+	MaxLength        *int          `json:"max_length,omitempty"`
+	Pattern          string        `json:"pattern,omitempty"`
+	Enum             []interface{} `json:"enum,omitempty"`
+	Const            interface{}   `json:"const,omitempty"`
+	MinItems         *int          `json:"min_items,omitempty"`
+	MaxItems         *int          `json:"max_items,omitempty"`
+	UniqueItems      bool          `json:"unique_items,omitempty"`
+	// MaxSize, in bytes, and MediaTypes apply to file parts, a media type may end
+	// with a wildcard subtype, e.g. "image/*"
+	MaxSize    *int64   `json:"max_size,omitempty"`
+	MediaTypes []string `json:"media_types,omitempty"`
 }
 
+// NumberPattern is the JSON number grammar (RFC 8259) the numeric constraints
+// are written in
+var NumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
 type Endpoint struct {
 	Path        string  `json:"path"`
 	Method      string  `json:"method"`
+	PathParams  []Field `json:"path_params"`
 	QueryParams []Field `json:"query_params"`
 	Headers     []Field `json:"headers"`
 	Body        []Field `json:"body"`
+	// ContentType is the expected media type of the body, e.g. "multipart/form-data"
+	ContentType string `json:"content_type,omitempty"`
+	Rules       []Rule `json:"rules,omitempty"`
+	// Requirements are keyed by section name, e.g. "body"
+	Requirements map[string]Requirements `json:"requirements,omitempty"`
+	// Responses are keyed by status code
+	Responses map[int]Response `json:"responses,omitempty"`
+}
+
+// Response describes what the endpoint returns with a status code
+type Response struct {
+	Headers []Field `json:"headers"`
+	Body    []Field `json:"body"`
+}
+
+// Rule is a check spanning several fields of the endpoint, evaluated after the
+// fields are validated. The request must satisfy Expr whenever it satisfies When,
+// an empty When always holds. Message explains the failure in the report.
+type Rule struct {
+	Name    string `json:"name"`
+	When    string `json:"when,omitempty"`
+	Expr    string `json:"expr"`
+	Message string `json:"message,omitempty"`
+}
+
+// Requirements make the fields of a section, or the properties of an object,
+// required depending on the other fields present. Fields are named as in the model.
+type Requirements struct {
+	// DependentRequired maps a field to the fields required whenever it's present
+	DependentRequired map[string][]string `json:"dependent_required,omitempty"`
+	RequiredIf        []RequiredIf        `json:"required_if,omitempty"`
+	// OneOfRequired lists the groups of which exactly one field must be present
+	OneOfRequired [][]string `json:"one_of_required,omitempty"`
+	// MutuallyExclusive lists the groups of which at most one field may be present
+	MutuallyExclusive [][]string `json:"mutually_exclusive,omitempty"`
 }
 
+// RequiredIf makes the Required fields required when Field has one of Values
+type RequiredIf struct {
+	Field    string        `json:"field"`
+	Values   []interface{} `json:"values"`
+	Required []string      `json:"required"`
+}
+
+// FieldModel describes the expected value of a field. Object values are described
+// by Properties, keyed by property name, and list values by the Items model.
 type FieldModel struct {
-	Types    []string
-	Required bool
+	Types       []string
+	Required    bool
+	Properties  map[string]FieldModel `json:",omitempty"`
+	Items       *FieldModel           `json:",omitempty"`
+	Constraints *Constraints          `json:",omitempty"`
+	// Requirements apply to the Properties
+	Requirements *Requirements `json:",omitempty"`
+	AllOf        []FieldModel  `json:",omitempty"`
+	AnyOf        []FieldModel  `json:",omitempty"`
+	OneOf        []FieldModel  `json:",omitempty"`
+	Not          *FieldModel   `json:",omitempty"`
 }
 
 type EndpointModel struct {
 	Path        string                `json:"path"`
 	Method      string                `json:"method"`
+	PathParams  map[string]FieldModel `json:"path_params"`
 	QueryParams map[string]FieldModel `json:"query_params"`
 	Headers     map[string]FieldModel `json:"headers"`
 	Body        map[string]FieldModel `json:"body"`
+	ContentType string                `json:"content_type,omitempty"`
+	Rules       []Rule                `json:"rules,omitempty"`
+	// Requirements are keyed by section name, e.g. "body"
+	Requirements map[string]Requirements `json:"requirements,omitempty"`
+	// Responses are keyed by status code
+	Responses map[int]ResponseModel `json:"responses,omitempty"`
+}
+
+type ResponseModel struct {
+	Headers map[string]FieldModel `json:"headers"`
+	Body    map[string]FieldModel `json:"body"`
 }
 
 func Decode(r *http.Request) ([]Endpoint, error) {
@@ -49,31 +176,47 @@ func Decode(r *http.Request) ([]Endpoint, error) {
 	return endpoints, nil
 }
 
-func StoreModels(db Store, models []Endpoint) error {
-	for _, model := range models {
-		err := StoreModel(db, &model)
-		if err != nil {
-			return err
-		}
+// StoreModels stores all models at once, so concurrent readers never observe a partial upload.
+// Each model is recorded as a new revision by the author.
+func StoreModels(db Store, models []Endpoint, author string) error {
+	endpointModels := make([]*EndpointModel, 0, len(models))
+	for i := range models {
+		endpointModels = append(endpointModels, newEndpointModel(&models[i]))
 	}
-	return nil
+	return storeEndpointModels(db, endpointModels, author)
+}
+
+func storeEndpointModels(db Store, endpointModels []*EndpointModel, author string) error {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	records, err := withRevisions(db, endpointModels, author)
+	if err != nil {
+		return err
+	}
+	return db.InsertBatch(records)
 }
 
 func generateKey(path, method string) string {
 	return fmt.Sprintf("%s-%s", path, method)
 }
 
-func StoreModel(db Store, model *Endpoint) error {
-	key := generateKey(model.Path, model.Method)
+func StoreModel(db Store, model *Endpoint, author string) error {
+	return storeEndpointModels(db, []*EndpointModel{newEndpointModel(model)}, author)
+}
 
-	endpointModel := NewModel().
+func newEndpointModel(model *Endpoint) *EndpointModel {
+	return NewModel().
 		WithPath(model.Path).
 		WithMethod(model.Method).
+		WithPathParams(model.PathParams).
 		WithQueryParams(model.QueryParams).
 		WithHeaders(model.Headers).
-		WithBody(model.Body)
-
-	return db.Insert(key, endpointModel)
+		WithBody(model.Body).
+		WithContentType(model.ContentType).
+		WithRules(model.Rules).
+		WithRequirements(model.Requirements).
+		WithResponses(model.Responses)
 }
 
 func NewModel() *EndpointModel {
@@ -98,6 +241,15 @@ func (em *EndpointModel) WithMethod(method string) *EndpointModel {
 	return em
 }
 
+func (em *EndpointModel) WithPathParams(pathParams []Field) *EndpointModel {
+	if em == nil {
+		return nil
+	}
+
+	em.PathParams = rawFeildToFieldModel(pathParams)
+	return em
+}
+
 func (em *EndpointModel) WithQueryParams(queryParams []Field) *EndpointModel {
 	if em == nil {
 		return nil
@@ -125,7 +277,74 @@ func (em *EndpointModel) WithBody(body []Field) *EndpointModel {
 	return em
 }
 
-func GetModel(db Store, path, method string) (*EndpointModel, error) {
+func (em *EndpointModel) WithContentType(contentType string) *EndpointModel {
+	if em == nil {
+		return nil
+	}
+
+	em.ContentType = contentType
+	return em
+}
+
+func (em *EndpointModel) WithRules(rules []Rule) *EndpointModel {
+	if em == nil {
+		return nil
+	}
+
+	em.Rules = append([]Rule(nil), rules...)
+	return em
+}
+
+func (em *EndpointModel) WithRequirements(requirements map[string]Requirements) *EndpointModel {
+	if em == nil {
+		return nil
+	}
+
+	em.Requirements = cloneRequirements(requirements)
+	return em
+}
+
+func (em *EndpointModel) WithResponses(responses map[int]Response) *EndpointModel {
+	if em == nil {
+		return nil
+	}
+
+	if responses == nil {
+		em.Responses = nil
+		return em
+	}
+	em.Responses = make(map[int]ResponseModel, len(responses))
+	for status, response := range responses {
+		em.Responses[status] = ResponseModel{
+			Headers: rawFeildToFieldModel(response.Headers),
+			Body:    rawFeildToFieldModel(response.Body),
+		}
+	}
+	return em
+}
+
+// GetModel returns the model stored for the exact path and method. When no such
+// model exists, the concrete path is resolved against the stored path templates
+// and the most specific matching template is returned.
+func GetModel(db Reader, path, method string) (*EndpointModel, error) {
+	endpointModel, err := GetExactModel(db, path, method)
+	if err != nil {
+		templateModel, matchErr := matchTemplate(db, path, method)
+		if matchErr != nil {
+			return nil, matchErr
+		}
+		if templateModel == nil {
+			return nil, err
+		}
+		return templateModel, nil
+	}
+
+	return endpointModel, nil
+}
+
+// GetExactModel returns the model stored for the path and method without
+// resolving path templates, e.g. path "/users/{id}" returns the template itself
+func GetExactModel(db Reader, path, method string) (*EndpointModel, error) {
 	key := generateKey(path, method)
 	record, err := db.Get(key)
 	if err != nil {
@@ -140,15 +359,124 @@ func GetModel(db Store, path, method string) (*EndpointModel, error) {
 	return endpointModel, nil
 }
 
+// DeleteModel removes the model stored for the exact path and method
+func DeleteModel(db Store, path, method string) error {
+	return db.Delete(generateKey(path, method))
+}
+
+// Filter narrows down ListModels results. Empty values match every model.
+type Filter struct {
+	PathPrefix string
+	Method     string
+}
+
+func (f Filter) matches(endpointModel *EndpointModel) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, endpointModel.Method) {
+		return false
+	}
+	return strings.HasPrefix(endpointModel.Path, f.PathPrefix)
+}
+
+// GetModels returns every endpoint model in the store ordered by path and method
+func GetModels(db Reader) ([]*EndpointModel, error) {
+	return ListModels(db, Filter{})
+}
+
+// ListModels returns the endpoint models matching the filter ordered by path and method
+func ListModels(db Reader, filter Filter) ([]*EndpointModel, error) {
+	records, err := db.List()
+	if err != nil {
+		return nil, err
+	}
+
+	endpointModels := []*EndpointModel{}
+	for _, record := range records {
+		if endpointModel, ok := record.(*EndpointModel); ok && filter.matches(endpointModel) {
+			endpointModels = append(endpointModels, endpointModel)
+		}
+	}
+
+	sort.Slice(endpointModels, func(i, j int) bool {
+		if endpointModels[i].Path != endpointModels[j].Path {
+			return endpointModels[i].Path < endpointModels[j].Path
+		}
+		return endpointModels[i].Method < endpointModels[j].Method
+	})
+	return endpointModels, nil
+}
+
+// matchTemplate looks for the most specific templated model matching the concrete
+// path and method. It returns nil when no template matches.
+func matchTemplate(db Reader, path, method string) (*EndpointModel, error) {
+	records, err := db.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var best *EndpointModel
+	for _, record := range records {
+		endpointModel, ok := record.(*EndpointModel)
+		if !ok || endpointModel.Method != method || !IsTemplate(endpointModel.Path) {
+			continue
+		}
+
+		if _, ok := MatchPath(endpointModel.Path, path); !ok {
+			continue
+		}
+
+		if best == nil || moreSpecific(endpointModel.Path, best.Path) ||
+			(!moreSpecific(best.Path, endpointModel.Path) && endpointModel.Path < best.Path) {
+			best = endpointModel
+		}
+	}
+	return best, nil
+}
+
 func rawFeildToFieldModel(fields []Field) map[string]FieldModel {
 	fieldModel := make(map[string]FieldModel, len(fields))
 	for _, field := range fields {
-		types := make([]string, len(field.Types))
-		copy(types, field.Types)
-		fieldModel[field.Name] = FieldModel{
-			Types:    field.Types,
-			Required: field.Required,
-		}
+		fieldModel[field.Name] = rawFieldToFieldModel(field)
+	}
+	return fieldModel
+}
+
+func rawFieldToFieldModel(field Field) FieldModel {
+	types := make([]string, len(field.Types))
+	copy(types, field.Types)
+	fieldModel := FieldModel{
+		Types:        types,
+		Required:     field.Required,
+		Constraints:  field.Constraints,
+		Requirements: field.Requirements,
+	}
+
+	if len(field.Properties) > 0 {
+		fieldModel.Properties = rawFeildToFieldModel(field.Properties)
+	}
+
+	if field.Items != nil {
+		items := rawFieldToFieldModel(*field.Items)
+		fieldModel.Items = &items
+	}
+
+	fieldModel.AllOf = rawFieldsToBranches(field.AllOf)
+	fieldModel.AnyOf = rawFieldsToBranches(field.AnyOf)
+	fieldModel.OneOf = rawFieldsToBranches(field.OneOf)
+	if field.Not != nil {
+		not := rawFieldToFieldModel(*field.Not)
+		fieldModel.Not = &not
 	}
 	return fieldModel
 }
+
+func rawFieldsToBranches(fields []Field) []FieldModel {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	branches := make([]FieldModel, 0, len(fields))
+	for _, field := range fields {
+		branches = append(branches, rawFieldToFieldModel(field))
+	}
+	return branches
+}
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/evgeniron/API-Validator/store"
@@ -46,24 +48,34 @@ func TestGetModel(t *testing.T) {
 			data: &EndpointModel{
 				"path",
 				"POST",
+				map[string]FieldModel{
+					"testing0": {
+						Types:    []string{"0"},
+						Required: true,
+					},
+				},
 				map[string]FieldModel{
 					"testing1": {
-						[]string{"1", "2"},
-						true,
+						Types:    []string{"1", "2"},
+						Required: true,
 					},
 				},
 				map[string]FieldModel{
 					"testing2": {
-						[]string{"3", "4"},
-						true,
+						Types:    []string{"3", "4"},
+						Required: true,
 					},
 				},
 				map[string]FieldModel{
 					"testing3": {
-						[]string{"5", "6"},
-						true,
+						Types:    []string{"5", "6"},
+						Required: true,
 					},
 				},
+				"",
+				nil,
+				nil,
+				nil,
 			},
 			expectedErr: false,
 		},
@@ -81,3 +93,291 @@ func TestGetModel(t *testing.T) {
 		require.Equal(t, tt.data, result)
 	}
 }
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		params   map[string]string
+		matched  bool
+	}{
+		{
+			template: "/users/{id}",
+			path:     "/users/42",
+			params:   map[string]string{"id": "42"},
+			matched:  true,
+		},
+		{
+			template: "/users/{id}/orders/{order_id}",
+			path:     "/users/42/orders/7",
+			params:   map[string]string{"id": "42", "order_id": "7"},
+			matched:  true,
+		},
+		{
+			template: "/users/{id}",
+			path:     "/users/42/orders",
+			matched:  false,
+		},
+		{
+			template: "/users/{id}",
+			path:     "/orders/42",
+			matched:  false,
+		},
+		{
+			template: "/users/info",
+			path:     "/users/info",
+			params:   map[string]string{},
+			matched:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template+" "+tt.path, func(t *testing.T) {
+			params, matched := MatchPath(tt.template, tt.path)
+			require.Equal(t, tt.matched, matched)
+			if tt.matched {
+				require.Equal(t, tt.params, params)
+			}
+		})
+	}
+}
+
+func TestGetModelTemplate(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+
+	models := []Endpoint{
+		{Path: "/users/{id}", Method: "GET"},
+		{Path: "/users/{id}/orders/{order_id}", Method: "GET"},
+		{Path: "/users/{id}/orders/latest", Method: "GET"},
+		{Path: "/users/info", Method: "GET"},
+		{Path: "/users/{id}", Method: "DELETE"},
+	}
+	require.NoError(t, StoreModels(db, models, "test"))
+
+	tests := []struct {
+		path        string
+		method      string
+		expected    string
+		expectedErr bool
+	}{
+		{path: "/users/42", method: "GET", expected: "/users/{id}"},
+		{path: "/users/info", method: "GET", expected: "/users/info"},
+		{path: "/users/42/orders/7", method: "GET", expected: "/users/{id}/orders/{order_id}"},
+		{path: "/users/42/orders/latest", method: "GET", expected: "/users/{id}/orders/latest"},
+		{path: "/users/42", method: "DELETE", expected: "/users/{id}"},
+		{path: "/users/42", method: "POST", expectedErr: true},
+		{path: "/orders/42", method: "GET", expectedErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			result, err := GetModel(db, tt.path, tt.method)
+			if tt.expectedErr {
+				var e *store.RecordNotFoundError
+				require.ErrorAs(t, err, &e)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, result.Path)
+			require.Equal(t, tt.method, result.Method)
+		})
+	}
+}
+
+func TestNestedFieldModel(t *testing.T) {
+	body := `[{
+		"path": "/orders/create",
+		"method": "POST",
+		"body": [
+			{
+				"name": "address",
+				"types": ["Object"],
+				"required": true,
+				"properties": [
+					{"name": "city", "types": ["String"], "required": true}
+				]
+			},
+			{
+				"name": "items",
+				"types": ["List"],
+				"items": {
+					"types": ["Object"],
+					"properties": [
+						{"name": "sku", "types": ["UUID"], "required": true}
+					]
+				}
+			}
+		]
+	}]`
+	r, err := http.NewRequest(http.MethodPut, "/", strings.NewReader(body))
+	require.NoError(t, err)
+
+	models, err := Decode(r)
+	require.NoError(t, err)
+
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	require.NoError(t, StoreModels(db, models, "test"))
+
+	result, err := GetModel(db, "/orders/create", "POST")
+	require.NoError(t, err)
+
+	require.Equal(t, FieldModel{
+		Types:    []string{"Object"},
+		Required: true,
+		Properties: map[string]FieldModel{
+			"city": {Types: []string{"String"}, Required: true},
+		},
+	}, result.Body["address"])
+	require.Equal(t, &FieldModel{
+		Types: []string{"Object"},
+		Properties: map[string]FieldModel{
+			"sku": {Types: []string{"UUID"}, Required: true},
+		},
+	}, result.Body["items"].Items)
+}
+
+func TestListModels(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+
+	require.NoError(t, StoreModels(db, []Endpoint{
+		{Path: "/users/info", Method: "GET"},
+		{Path: "/users/create", Method: "POST"},
+		{Path: "/orders/info", Method: "GET"},
+	}, "test"))
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected []string
+	}{
+		{name: "All", filter: Filter{}, expected: []string{"/orders/info", "/users/create", "/users/info"}},
+		{name: "Path prefix", filter: Filter{PathPrefix: "/users"}, expected: []string{"/users/create", "/users/info"}},
+		{name: "Method", filter: Filter{Method: "get"}, expected: []string{"/orders/info", "/users/info"}},
+		{name: "No match", filter: Filter{PathPrefix: "/users", Method: "DELETE"}, expected: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			models, err := ListModels(db, tt.filter)
+			require.NoError(t, err)
+
+			paths := []string{}
+			for _, endpointModel := range models {
+				paths = append(paths, endpointModel.Path)
+			}
+			require.Equal(t, tt.expected, paths)
+		})
+	}
+
+	require.NoError(t, DeleteModel(db, "/users/info", "GET"))
+	_, err = GetModel(db, "/users/info", "GET")
+	var e *store.RecordNotFoundError
+	require.ErrorAs(t, err, &e)
+}
+
+func TestPatchModel(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+
+	require.NoError(t, StoreModel(db, &Endpoint{
+		Path:   "/users/create",
+		Method: "POST",
+		Body: []Field{
+			{Name: "firstName", Types: []string{"String"}, Required: true},
+			{Name: "phone", Types: []string{"String"}},
+		},
+	}, "test"))
+
+	tests := []struct {
+		name        string
+		patches     []FieldPatch
+		expectedErr bool
+		expected    map[string]FieldModel
+	}{
+		{
+			name: "Add, replace and remove",
+			patches: []FieldPatch{
+				{Op: PatchAdd, Section: "body", Field: &Field{Name: "email", Types: []string{"Email"}, Required: true}},
+				{Op: PatchReplace, Section: "body", Field: &Field{Name: "firstName", Types: []string{"String"}}},
+				{Op: PatchRemove, Section: "body", Name: "phone"},
+			},
+			expected: map[string]FieldModel{
+				"firstName": {Types: []string{"String"}},
+				"email":     {Types: []string{"Email"}, Required: true},
+			},
+		},
+		{
+			name: "Add existing field",
+			patches: []FieldPatch{
+				{Op: PatchRemove, Section: "body", Name: "email"},
+				{Op: PatchAdd, Section: "body", Field: &Field{Name: "firstName", Types: []string{"String"}}},
+			},
+			expectedErr: true,
+		},
+		{
+			name:        "Remove missing field",
+			patches:     []FieldPatch{{Op: PatchRemove, Section: "body", Name: "lastName"}},
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown section",
+			patches:     []FieldPatch{{Op: PatchRemove, Section: "cookies", Name: "session"}},
+			expectedErr: true,
+		},
+		{
+			name:        "Unknown op",
+			patches:     []FieldPatch{{Op: "move", Section: "body", Name: "firstName"}},
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := GetExactModel(db, "/users/create", "POST")
+			require.NoError(t, err)
+
+			patched, err := PatchModel(db, "/users/create", "POST", tt.patches, "test")
+			if tt.expectedErr {
+				var e *PatchError
+				require.ErrorAs(t, err, &e)
+
+				after, err := GetExactModel(db, "/users/create", "POST")
+				require.NoError(t, err)
+				require.Equal(t, before, after)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, patched.Body)
+		})
+	}
+
+	_, err = PatchModel(db, "/users/delete", "POST", nil, "test")
+	var e *store.RecordNotFoundError
+	require.ErrorAs(t, err, &e)
+}
+
+func TestFileStoreModels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+
+	file, err := os.Open("test_data/models.json")
+	require.NoError(t, err)
+	defer file.Close()
+
+	r, err := http.NewRequest(http.MethodPut, "/", file)
+	require.NoError(t, err)
+	models, err := Decode(r)
+	require.NoError(t, err)
+
+	db, err := store.NewFileDB(path, RecordKinds)
+	require.NoError(t, err)
+	require.NoError(t, StoreModels(db, models, "test"))
+
+	expected, err := GetModels(db)
+	require.NoError(t, err)
+
+	reloaded, err := store.NewFileDB(path, RecordKinds)
+	require.NoError(t, err)
+
+	result, err := GetModels(reloaded)
+	require.NoError(t, err)
+	require.Equal(t, expected, result)
+}
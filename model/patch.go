@@ -0,0 +1,169 @@
+package model
+
+import "fmt"
+
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+)
+
+// FieldPatch adds, removes or replaces a single top level field in one of the
+// endpoint model sections ("path_params", "query_params", "headers" or "body").
+// Remove operations only need the field name, add and replace need the field.
+type FieldPatch struct {
+	Op      string `json:"op"`
+	Section string `json:"section"`
+	Name    string `json:"name"`
+	Field   *Field `json:"field"`
+}
+
+// PatchError reports a patch that cannot be applied to the model
+type PatchError struct {
+	Index   int
+	Message string
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("patch %d: %s", e.Index, e.Message)
+}
+
+// PatchModel applies the patches to the model stored for the exact path and method.
+// Either every patch is applied, recording a new revision by the author, or the stored
+// model is left untouched.
+func PatchModel(db Store, path, method string, patches []FieldPatch, author string) (*EndpointModel, error) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	endpointModel, err := GetExactModel(db, path, method)
+	if err != nil {
+		return nil, err
+	}
+
+	patched := endpointModel.clone()
+	for i, patch := range patches {
+		if err := patched.apply(patch); err != nil {
+			return nil, &PatchError{Index: i, Message: err.Error()}
+		}
+	}
+
+	records, err := withRevisions(db, []*EndpointModel{patched}, author)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.InsertBatch(records); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+func (em *EndpointModel) apply(patch FieldPatch) error {
+	section := em.section(patch.Section)
+	if section == nil {
+		return fmt.Errorf("unknown section %q", patch.Section)
+	}
+
+	name := patch.Name
+	if patch.Field != nil && name == "" {
+		name = patch.Field.Name
+	}
+	if name == "" {
+		return fmt.Errorf("missing field name")
+	}
+
+	_, exists := (*section)[name]
+	switch patch.Op {
+	case PatchAdd, PatchReplace:
+		if patch.Field == nil {
+			return fmt.Errorf("missing field for %s", patch.Op)
+		}
+		if patch.Op == PatchAdd && exists {
+			return fmt.Errorf("field %q already exists in %s", name, patch.Section)
+		}
+		if patch.Op == PatchReplace && !exists {
+			return fmt.Errorf("field %q does not exist in %s", name, patch.Section)
+		}
+		field := *patch.Field
+		field.Name = name
+		(*section)[name] = rawFieldToFieldModel(field)
+	case PatchRemove:
+		if !exists {
+			return fmt.Errorf("field %q does not exist in %s", name, patch.Section)
+		}
+		delete(*section, name)
+	default:
+		return fmt.Errorf("unknown op %q", patch.Op)
+	}
+	return nil
+}
+
+func (em *EndpointModel) section(name string) *map[string]FieldModel {
+	var section *map[string]FieldModel
+	switch name {
+	case "path_params":
+		section = &em.PathParams
+	case "query_params":
+		section = &em.QueryParams
+	case "headers":
+		section = &em.Headers
+	case "body":
+		section = &em.Body
+	default:
+		return nil
+	}
+
+	if *section == nil {
+		*section = make(map[string]FieldModel)
+	}
+	return section
+}
+
+// clone copies the model sections so that patches never modify a stored model in place
+func (em *EndpointModel) clone() *EndpointModel {
+	clone := *em
+	clone.PathParams = cloneFieldModels(em.PathParams)
+	clone.QueryParams = cloneFieldModels(em.QueryParams)
+	clone.Headers = cloneFieldModels(em.Headers)
+	clone.Body = cloneFieldModels(em.Body)
+	clone.Rules = append([]Rule(nil), em.Rules...)
+	clone.Requirements = cloneRequirements(em.Requirements)
+	clone.Responses = cloneResponses(em.Responses)
+	return &clone
+}
+
+func cloneFieldModels(fieldModels map[string]FieldModel) map[string]FieldModel {
+	if fieldModels == nil {
+		return nil
+	}
+
+	clone := make(map[string]FieldModel, len(fieldModels))
+	for name, fieldModel := range fieldModels {
+		clone[name] = fieldModel
+	}
+	return clone
+}
+
+func cloneRequirements(requirements map[string]Requirements) map[string]Requirements {
+	if requirements == nil {
+		return nil
+	}
+
+	clone := make(map[string]Requirements, len(requirements))
+	for section, sectionRequirements := range requirements {
+		clone[section] = sectionRequirements
+	}
+	return clone
+}
+
+func cloneResponses(responses map[int]ResponseModel) map[int]ResponseModel {
+	if responses == nil {
+		return nil
+	}
+
+	clone := make(map[int]ResponseModel, len(responses))
+	for status, response := range responses {
+		clone[status] = ResponseModel{Headers: cloneFieldModels(response.Headers), Body: cloneFieldModels(response.Body)}
+	}
+	return clone
+}
@@ -0,0 +1,73 @@
+package model
+
+import "strings"
+
+/*
+Path templates use the OpenAPI syntax, where a whole path segment wrapped in braces
+captures a path parameter, e.g. "/users/{id}/orders/{order_id}".
+A concrete path matches a template when both have the same number of segments and
+every literal segment is equal.
+*/
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func paramName(segment string) (string, bool) {
+	if len(segment) < 3 || segment[0] != '{' || segment[len(segment)-1] != '}' {
+		return "", false
+	}
+	return segment[1 : len(segment)-1], true
+}
+
+// IsTemplate reports whether path contains at least one path parameter segment
+func IsTemplate(path string) bool {
+	for _, segment := range splitPath(path) {
+		if _, ok := paramName(segment); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPath matches a concrete path against a path template and returns the
+// captured path parameter values by parameter name
+func MatchPath(template, path string) (map[string]string, bool) {
+	templateSegments := splitPath(template)
+	pathSegments := splitPath(path)
+	if len(templateSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range templateSegments {
+		if name, ok := paramName(segment); ok {
+			if pathSegments[i] == "" {
+				return nil, false
+			}
+			params[name] = pathSegments[i]
+			continue
+		}
+
+		if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// moreSpecific reports whether template a is more specific than template b.
+// Both templates are expected to match the same path, so the first segment where
+// one has a literal and the other a parameter decides.
+func moreSpecific(a, b string) bool {
+	aSegments := splitPath(a)
+	bSegments := splitPath(b)
+	for i := 0; i < len(aSegments) && i < len(bSegments); i++ {
+		_, aParam := paramName(aSegments[i])
+		_, bParam := paramName(bSegments[i])
+		if aParam != bParam {
+			return bParam
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+/*
+Teams define their own field types declaratively. A type definition names a base type,
+e.g. "String", and restricts it with constraints and an optional date layout, so a
+field with the type "SKU" is validated as a String matching the SKU pattern.
+Type definitions are stored next to the models and are not versioned.
+*/
+
+type TypeDefinition struct {
+	Name string `json:"name"`
+	Base string `json:"base"`
+	Constraints
+	// DateLayout is the Go time layout string values must parse with, e.g. "2006-01-02"
+	DateLayout string `json:"date_layout,omitempty"`
+}
+
+// ModelFile is a models file with its own type definitions. Models files are
+// either a ModelFile object or a plain array of models.
+type ModelFile struct {
+	Types  []TypeDefinition `json:"types"`
+	Models []Endpoint       `json:"models"`
+}
+
+// DecodeModelFile decodes a ModelFile object or a plain array of models
+func DecodeModelFile(r io.Reader) (*ModelFile, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error decoding models: %w", err)
+	}
+
+	var file ModelFile
+	var target interface{} = &file
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		target = &file.Models
+	}
+	if err := unmarshalNumbers(raw, target); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func unmarshalNumbers(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("error decoding models: %w", err)
+	}
+	return nil
+}
+
+func typeKey(name string) string {
+	return "type:" + name
+}
+
+// StoreModelFile stores the type definitions and the models of the file at once
+func StoreModelFile(db Store, file *ModelFile, author string) error {
+	endpointModels := make([]*EndpointModel, 0, len(file.Models))
+	for i := range file.Models {
+		endpointModels = append(endpointModels, newEndpointModel(&file.Models[i]))
+	}
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	records, err := withRevisions(db, endpointModels, author)
+	if err != nil {
+		return err
+	}
+	for i := range file.Types {
+		definition := file.Types[i]
+		records[typeKey(definition.Name)] = &definition
+	}
+	return db.InsertBatch(records)
+}
+
+// StoreTypes stores the type definitions, replacing the definitions with the same names
+func StoreTypes(db Store, definitions []TypeDefinition) error {
+	return StoreModelFile(db, &ModelFile{Types: definitions}, "")
+}
+
+func GetType(db Reader, name string) (*TypeDefinition, error) {
+	record, err := db.Get(typeKey(name))
+	if err != nil {
+		return nil, err
+	}
+
+	definition, ok := record.(*TypeDefinition)
+	if !ok {
+		return nil, fmt.Errorf("incorrect type - expecting TypeDefinition type")
+	}
+	return definition, nil
+}
+
+// GetTypes returns every type definition in the store ordered by name
+func GetTypes(db Reader) ([]*TypeDefinition, error) {
+	records, err := db.List()
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := []*TypeDefinition{}
+	for _, record := range records {
+		if definition, ok := record.(*TypeDefinition); ok {
+			definitions = append(definitions, definition)
+		}
+	}
+
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions, nil
+}
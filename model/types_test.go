@@ -0,0 +1,85 @@
+package model
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeModelFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *ModelFile
+	}{
+		{
+			name:     "Array of models",
+			input:    `[{"path": "/items", "method": "GET"}]`,
+			expected: &ModelFile{Models: []Endpoint{{Path: "/items", Method: "GET"}}},
+		},
+		{
+			name: "Models with types",
+			input: `{
+				"types": [{"name": "Percent", "base": "Int", "maximum": 100}],
+				"models": [{"path": "/items", "method": "GET", "query_params": [{"name": "discount", "types": ["Percent"]}]}]
+			}`,
+			expected: &ModelFile{
+				Types: []TypeDefinition{{Name: "Percent", Base: "Int", Constraints: Constraints{Maximum: "100"}}},
+				Models: []Endpoint{{
+					Path:        "/items",
+					Method:      "GET",
+					QueryParams: []Field{{Name: "discount", Types: []string{"Percent"}}},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := DecodeModelFile(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, file)
+		})
+	}
+
+	_, err := DecodeModelFile(strings.NewReader(`{"models": {}}`))
+	require.Error(t, err)
+}
+
+func TestStoreModelFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+	db, err := store.NewFileDB(path, RecordKinds)
+	require.NoError(t, err)
+
+	file := &ModelFile{
+		Types: []TypeDefinition{
+			{Name: "SKU", Base: "String", Constraints: Constraints{Pattern: "^[A-Z]{3}-[0-9]+$"}},
+			{Name: "Country", Base: "String", Constraints: Constraints{Enum: []interface{}{"US", json.Number("49")}}},
+		},
+		Models: []Endpoint{{Path: "/items", Method: "GET", Body: []Field{{Name: "sku", Types: []string{"SKU"}}}}},
+	}
+	require.NoError(t, StoreModelFile(db, file, "test"))
+	require.NoError(t, StoreTypes(db, []TypeDefinition{{Name: "OrderDate", Base: "String", DateLayout: "01/02/2006"}}))
+
+	reloaded, err := store.NewFileDB(path, RecordKinds)
+	require.NoError(t, err)
+
+	definitions, err := GetTypes(reloaded)
+	require.NoError(t, err)
+	require.Equal(t, []*TypeDefinition{&file.Types[1], {Name: "OrderDate", Base: "String", DateLayout: "01/02/2006"}, &file.Types[0]}, definitions)
+
+	definition, err := GetType(reloaded, "SKU")
+	require.NoError(t, err)
+	require.Equal(t, &file.Types[0], definition)
+
+	models, err := GetModels(reloaded)
+	require.NoError(t, err)
+	require.Len(t, models, 1)
+
+	history, err := GetHistory(reloaded, "/items", "GET")
+	require.NoError(t, err)
+	require.Len(t, history.Revisions, 1)
+}
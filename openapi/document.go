@@ -0,0 +1,203 @@
+package openapi
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Document is the subset of the OpenAPI 3 specification the validator understands.
+Both JSON and YAML documents are parsed with the YAML decoder, since JSON is valid YAML.
+*/
+
+type Document struct {
+	OpenAPI    string               `json:"openapi" yaml:"openapi"`
+	Info       Info                 `json:"info" yaml:"info"`
+	Paths      map[string]*PathItem `json:"paths" yaml:"paths"`
+	Components *Components          `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	Parameters    map[string]*Parameter   `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty" yaml:"requestBodies,omitempty"`
+}
+
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty" yaml:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty" yaml:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Options    *Operation   `json:"options,omitempty" yaml:"options,omitempty"`
+	Head       *Operation   `json:"head,omitempty" yaml:"head,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Trace      *Operation   `json:"trace,omitempty" yaml:"trace,omitempty"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses,omitempty" yaml:"responses,omitempty"`
+}
+
+type Parameter struct {
+	Ref      string  `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Name     string  `json:"name,omitempty" yaml:"name,omitempty"`
+	In       string  `json:"in,omitempty" yaml:"in,omitempty"`
+	Required bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Ref      string                `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Required bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type Response struct {
+	Description string                `json:"description" yaml:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type Header struct {
+	Required bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format     string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Pattern    string             `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	AllOf      []*Schema          `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	AnyOf      []*Schema          `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	OneOf      []*Schema          `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	Not        *Schema            `json:"not,omitempty" yaml:"not,omitempty"`
+
+	Minimum    Number `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum    Number `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	MultipleOf Number `json:"multipleOf,omitempty" yaml:"multipleOf,omitempty"`
+	// ExclusiveMinimum and ExclusiveMaximum are booleans qualifying Minimum and
+	// Maximum in OpenAPI 3.0, and numbers of their own in OpenAPI 3.1
+	ExclusiveMinimum interface{}   `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum interface{}   `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	MinLength        *int          `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength        *int          `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Enum             []interface{} `json:"enum,omitempty" yaml:"enum,omitempty"`
+	MinItems         *int          `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems         *int          `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	UniqueItems      bool          `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+
+	// XMaxSize and XMediaTypes carry the file constraints, which OpenAPI has no
+	// keywords for
+	XMaxSize    *int64   `json:"x-max-size,omitempty" yaml:"x-max-size,omitempty"`
+	XMediaTypes []string `json:"x-media-types,omitempty" yaml:"x-media-types,omitempty"`
+
+	// XTypes carries the validator type names of the model field, so that exported
+	// documents import back without losing types OpenAPI cannot express
+	XTypes []string `json:"x-types,omitempty" yaml:"x-types,omitempty"`
+}
+
+// Number is a number literal of a schema, written as a number in both JSON and
+// YAML documents
+type Number string
+
+func (n Number) MarshalJSON() ([]byte, error) {
+	return []byte(n), nil
+}
+
+func (n Number) MarshalYAML() (interface{}, error) {
+	tag := "!!int"
+	if strings.ContainsAny(string(n), ".eE") {
+		tag = "!!float"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(n)}, nil
+}
+
+// Parse decodes a JSON or YAML OpenAPI 3 document
+func Parse(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error decoding OpenAPI document: %w", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q - expecting 3.x", doc.OpenAPI)
+	}
+	return &doc, nil
+}
+
+// EncodeYAML writes the document as YAML
+func (d *Document) EncodeYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(d); err != nil {
+		return fmt.Errorf("error encoding OpenAPI document: %w", err)
+	}
+	return encoder.Close()
+}
+
+// setOperation sets the operation of the path item for the HTTP method and
+// reports whether the method is supported by OpenAPI
+func (p *PathItem) setOperation(method string, operation *Operation) bool {
+	switch strings.ToUpper(method) {
+	case "GET":
+		p.Get = operation
+	case "PUT":
+		p.Put = operation
+	case "POST":
+		p.Post = operation
+	case "DELETE":
+		p.Delete = operation
+	case "OPTIONS":
+		p.Options = operation
+	case "HEAD":
+		p.Head = operation
+	case "PATCH":
+		p.Patch = operation
+	case "TRACE":
+		p.Trace = operation
+	default:
+		return false
+	}
+	return true
+}
+
+// operations returns the operations of the path item keyed by HTTP method
+func (p *PathItem) operations() map[string]*Operation {
+	operations := map[string]*Operation{
+		"GET":     p.Get,
+		"PUT":     p.Put,
+		"POST":    p.Post,
+		"DELETE":  p.Delete,
+		"OPTIONS": p.Options,
+		"HEAD":    p.Head,
+		"PATCH":   p.Patch,
+		"TRACE":   p.Trace,
+	}
+	for method, operation := range operations {
+		if operation == nil {
+			delete(operations, method)
+		}
+	}
+	return operations
+}
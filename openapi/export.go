@@ -0,0 +1,284 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/evgeniron/API-Validator/model"
+)
+
+// typeSchemas maps the validator type names onto OpenAPI schemas. The Date type is
+// dd-mm-yyyy, only ISODate has the RFC 3339 "date" format.
+var typeSchemas = map[string]Schema{
+	"String":     {Type: "string"},
+	"Int":        {Type: "integer"},
+	"Int32":      {Type: "integer", Format: "int32"},
+	"Int64":      {Type: "integer", Format: "int64"},
+	"Float":      {Type: "number", Format: "double"},
+	"Decimal":    {Type: "number"},
+	"Boolean":    {Type: "boolean"},
+	"List":       {Type: "array"},
+	"Object":     {Type: "object"},
+	"UUID":       {Type: "string", Format: "uuid"},
+	"Email":      {Type: "string", Format: "email"},
+	"Date":       {Type: "string", Pattern: `^[0-9]{2}-[0-9]{2}-[0-9]{4}$`},
+	"ISODate":    {Type: "string", Format: "date"},
+	"BearerAuth": {Type: "string"},
+}
+
+// Export renders the endpoint models as an OpenAPI 3 document. Models with a
+// method OpenAPI has no operation for are left out.
+func Export(endpointModels []*model.EndpointModel) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: "API-Validator models", Version: "1.0.0"},
+		Paths:   make(map[string]*PathItem),
+	}
+
+	for _, endpointModel := range endpointModels {
+		item, ok := doc.Paths[endpointModel.Path]
+		if !ok {
+			item = &PathItem{}
+		}
+
+		if item.setOperation(endpointModel.Method, exportOperation(endpointModel)) {
+			doc.Paths[endpointModel.Path] = item
+		}
+	}
+	return doc
+}
+
+func exportOperation(endpointModel *model.EndpointModel) *Operation {
+	operation := &Operation{Responses: exportResponses(endpointModel.Responses)}
+
+	operation.Parameters = append(operation.Parameters, exportParameters("path", endpointModel.PathParams)...)
+	operation.Parameters = append(operation.Parameters, exportParameters("query", endpointModel.QueryParams)...)
+	operation.Parameters = append(operation.Parameters, exportParameters("header", endpointModel.Headers)...)
+
+	if len(endpointModel.Body) > 0 || endpointModel.ContentType != "" {
+		body := exportObject(endpointModel.Body)
+		contentType := endpointModel.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		operation.RequestBody = &RequestBody{
+			Required: len(body.Required) > 0,
+			Content: map[string]*MediaType{
+				contentType: {Schema: body},
+			},
+		}
+	}
+	return operation
+}
+
+// exportResponses renders the response models keyed by status code, a model
+// without responses gets a default response, as OpenAPI requires one
+func exportResponses(responseModels map[int]model.ResponseModel) map[string]*Response {
+	if len(responseModels) == 0 {
+		return map[string]*Response{
+			"default": {Description: "response is not modelled"},
+		}
+	}
+
+	responses := make(map[string]*Response, len(responseModels))
+	for status, responseModel := range responseModels {
+		response := &Response{Description: http.StatusText(status)}
+		if response.Description == "" {
+			response.Description = "status " + strconv.Itoa(status)
+		}
+
+		if len(responseModel.Headers) > 0 {
+			response.Headers = make(map[string]*Header, len(responseModel.Headers))
+		}
+		for name, fieldModel := range responseModel.Headers {
+			response.Headers[name] = &Header{Required: fieldModel.Required, Schema: exportSchema(fieldModel)}
+		}
+
+		if len(responseModel.Body) > 0 {
+			response.Content = map[string]*MediaType{
+				"application/json": {Schema: exportObject(responseModel.Body)},
+			}
+		}
+		responses[strconv.Itoa(status)] = response
+	}
+	return responses
+}
+
+func exportParameters(in string, fieldModels map[string]model.FieldModel) []*Parameter {
+	var parameters []*Parameter
+	for _, name := range sortedKeys(fieldModels) {
+		fieldModel := fieldModels[name]
+		parameters = append(parameters, &Parameter{
+			Name:     name,
+			In:       in,
+			Required: fieldModel.Required || in == "path",
+			Schema:   exportSchema(fieldModel),
+		})
+	}
+	return parameters
+}
+
+func exportObject(fieldModels map[string]model.FieldModel) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema, len(fieldModels))}
+	for _, name := range sortedKeys(fieldModels) {
+		schema.Properties[name] = exportSchema(fieldModels[name])
+		if fieldModels[name].Required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// exportSchema merges the field types into a single schema. Types with conflicting
+// OpenAPI types are combined with allOf, matching how the validator applies them,
+// and the combinator branches follow them.
+func exportSchema(fieldModel model.FieldModel) *Schema {
+	schema := &Schema{}
+	if fieldModel.Properties != nil {
+		schema = exportObject(fieldModel.Properties)
+	}
+
+	if fieldModel.Items != nil {
+		schema.Type = "array"
+		schema.Items = exportSchema(*fieldModel.Items)
+	}
+
+	var conflicting bool
+	for _, typeName := range fieldModel.Types {
+		typeSchema, ok := typeSchemas[typeName]
+		if !ok {
+			continue
+		}
+
+		schemaCopy := typeSchema
+		schema.AllOf = append(schema.AllOf, &schemaCopy)
+		if schema.Type != "" && schema.Type != typeSchema.Type {
+			conflicting = true
+		}
+		schema.Type = typeSchema.Type
+		if typeSchema.Format != "" {
+			schema.Format = typeSchema.Format
+		}
+		if typeSchema.Pattern != "" {
+			schema.Pattern = typeSchema.Pattern
+		}
+	}
+
+	if conflicting {
+		schema.Type = ""
+		schema.Format = ""
+		schema.Pattern = ""
+	} else {
+		schema.AllOf = nil
+	}
+
+	if fieldModel.Constraints != nil {
+		exportConstraints(schema, fieldModel.Constraints)
+	}
+
+	for _, branch := range fieldModel.AllOf {
+		schema.AllOf = append(schema.AllOf, exportSchema(branch))
+	}
+	for _, branch := range fieldModel.AnyOf {
+		schema.AnyOf = append(schema.AnyOf, exportSchema(branch))
+	}
+	for _, branch := range fieldModel.OneOf {
+		schema.OneOf = append(schema.OneOf, exportSchema(branch))
+	}
+	if fieldModel.Not != nil {
+		schema.Not = exportSchema(*fieldModel.Not)
+	}
+
+	if len(fieldModel.Types) > 0 {
+		schema.XTypes = append([]string{}, fieldModel.Types...)
+	}
+	return schema
+}
+
+// exportConstraints sets the schema keywords of the constraints. OpenAPI 3.0 has
+// exclusive bounds only as flags of minimum and maximum, an exclusive bound next
+// to an inclusive one goes into an allOf branch. Const, which OpenAPI 3.0 lacks,
+// becomes an enum of its value, which replaces an enum holding it and otherwise
+// goes into an allOf branch.
+func exportConstraints(schema *Schema, constraints *model.Constraints) {
+	schema.Minimum = Number(constraints.Minimum)
+	schema.Maximum = Number(constraints.Maximum)
+	if constraints.ExclusiveMinimum != "" {
+		bound := schema
+		if constraints.Minimum != "" {
+			bound = &Schema{}
+			schema.AllOf = append(schema.AllOf, bound)
+		}
+		bound.Minimum, bound.ExclusiveMinimum = Number(constraints.ExclusiveMinimum), true
+	}
+	if constraints.ExclusiveMaximum != "" {
+		bound := schema
+		if constraints.Maximum != "" {
+			bound = &Schema{}
+			schema.AllOf = append(schema.AllOf, bound)
+		}
+		bound.Maximum, bound.ExclusiveMaximum = Number(constraints.ExclusiveMaximum), true
+	}
+	schema.MultipleOf = Number(constraints.MultipleOf)
+
+	schema.MinLength = constraints.MinLength
+	schema.MaxLength = constraints.MaxLength
+	if constraints.Pattern != "" {
+		schema.Pattern = constraints.Pattern
+	}
+
+	for _, value := range constraints.Enum {
+		schema.Enum = append(schema.Enum, exportValue(value))
+	}
+	if constraints.Const != nil {
+		constant := []interface{}{exportValue(constraints.Const)}
+		switch {
+		case len(constraints.Enum) == 0 || inEnum(constraints.Const, constraints.Enum):
+			schema.Enum = constant
+		default:
+			schema.AllOf = append(schema.AllOf, &Schema{Enum: constant})
+		}
+	}
+
+	schema.MinItems = constraints.MinItems
+	schema.MaxItems = constraints.MaxItems
+	schema.UniqueItems = constraints.UniqueItems
+
+	schema.XMaxSize = constraints.MaxSize
+	schema.XMediaTypes = constraints.MediaTypes
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if reflect.DeepEqual(value, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// exportValue converts the numbers of a decoded JSON value to Number, so they
+// are written as numbers in YAML documents too
+func exportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		return Number(v)
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = exportValue(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = exportValue(item)
+		}
+		return object
+	}
+	return value
+}
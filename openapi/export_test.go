@@ -0,0 +1,199 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	endpointModel := model.NewModel().
+		WithPath("/users/{id}").
+		WithMethod("POST").
+		WithPathParams([]model.Field{{Name: "id", Types: []string{"String", "UUID"}, Required: true}}).
+		WithHeaders([]model.Field{{Name: "Authorization", Types: []string{"String", "Auth-Token"}, Required: true}}).
+		WithBody([]model.Field{
+			{Name: "age", Types: []string{"Int", "String"}},
+			{Name: "tags", Types: []string{"List"}, Items: &model.Field{Types: []string{"String"}}},
+			{Name: "born", Types: []string{"Date"}},
+			{Name: "joined", Types: []string{"String", "ISODate"}},
+		})
+	endpointModel.WithResponses(map[int]model.Response{
+		201: {
+			Headers: []model.Field{{Name: "Location", Types: []string{"String"}, Required: true}},
+			Body:    []model.Field{{Name: "id", Types: []string{"Int"}, Required: true}},
+		},
+		204: {},
+	})
+	unsupported := model.NewModel().WithPath("/users").WithMethod("CONNECT")
+
+	doc := Export([]*model.EndpointModel{endpointModel, unsupported})
+
+	require.Len(t, doc.Paths, 1)
+	operation := doc.Paths["/users/{id}"].Post
+	require.NotNil(t, operation)
+	require.Equal(t, []*Parameter{
+		{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string", Format: "uuid", XTypes: []string{"String", "UUID"}},
+		},
+		{
+			Name:     "Authorization",
+			In:       "header",
+			Required: true,
+			Schema:   &Schema{Type: "string", XTypes: []string{"String", "Auth-Token"}},
+		},
+	}, operation.Parameters)
+
+	body := operation.RequestBody.Content["application/json"].Schema
+	require.False(t, operation.RequestBody.Required)
+	require.Equal(t, &Schema{
+		AllOf:  []*Schema{{Type: "integer"}, {Type: "string"}},
+		XTypes: []string{"Int", "String"},
+	}, body.Properties["age"])
+	require.Equal(t, &Schema{
+		Type:   "array",
+		Items:  &Schema{Type: "string", XTypes: []string{"String"}},
+		XTypes: []string{"List"},
+	}, body.Properties["tags"])
+	require.Equal(t, &Schema{Type: "string", Pattern: `^[0-9]{2}-[0-9]{2}-[0-9]{4}$`, XTypes: []string{"Date"}}, body.Properties["born"])
+	require.Equal(t, &Schema{Type: "string", Format: "date", XTypes: []string{"String", "ISODate"}}, body.Properties["joined"])
+
+	require.Equal(t, map[string]*Response{
+		"201": {
+			Description: "Created",
+			Headers:     map[string]*Header{"Location": {Required: true, Schema: &Schema{Type: "string", XTypes: []string{"String"}}}},
+			Content: map[string]*MediaType{"application/json": {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"id": {Type: "integer", XTypes: []string{"Int"}}},
+				Required:   []string{"id"},
+			}}},
+		},
+		"204": {Description: "No Content"},
+	}, operation.Responses)
+
+	// an exclusive bound next to an inclusive one goes into an allOf branch
+	bounded := model.NewModel().WithPath("/scores").WithMethod("GET").WithQueryParams([]model.Field{
+		{Name: "score", Types: []string{"Decimal"}, Constraints: &model.Constraints{Minimum: "0", ExclusiveMinimum: "0", Const: json.Number("5")}},
+	})
+	require.Equal(t, &Schema{
+		Type:    "number",
+		Minimum: "0",
+		Enum:    []interface{}{Number("5")},
+		AllOf:   []*Schema{{Minimum: "0", ExclusiveMinimum: true}},
+		XTypes:  []string{"Decimal"},
+	}, Export([]*model.EndpointModel{bounded}).Paths["/scores"].Get.Parameters[0].Schema)
+
+	// a const in the enum replaces it, any other const goes into an allOf branch
+	enumerated := model.NewModel().WithPath("/levels").WithMethod("GET").WithQueryParams([]model.Field{
+		{Name: "known", Types: []string{"String"}, Constraints: &model.Constraints{Enum: []interface{}{"low", "high"}, Const: "high"}},
+		{Name: "unknown", Types: []string{"String"}, Constraints: &model.Constraints{Enum: []interface{}{"low", "high"}, Const: "max"}},
+	})
+	parameters := Export([]*model.EndpointModel{enumerated}).Paths["/levels"].Get.Parameters
+	require.Equal(t, &Schema{Type: "string", Enum: []interface{}{"high"}, XTypes: []string{"String"}}, parameters[0].Schema)
+	require.Equal(t, &Schema{
+		Type:   "string",
+		Enum:   []interface{}{"low", "high"},
+		AllOf:  []*Schema{{Enum: []interface{}{"max"}}},
+		XTypes: []string{"String"},
+	}, parameters[1].Schema)
+
+	// models without responses get the default response OpenAPI requires
+	get := model.NewModel().WithPath("/users").WithMethod("GET")
+	require.Equal(t, map[string]*Response{
+		"default": {Description: "response is not modelled"},
+	}, Export([]*model.EndpointModel{get}).Paths["/users"].Get.Responses)
+
+	var yamlDocument bytes.Buffer
+	require.NoError(t, doc.EncodeYAML(&yamlDocument))
+	require.Contains(t, yamlDocument.String(), "x-types:")
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	two, eight, maxSize := 2, 8, int64(1<<20)
+	endpoints := []model.Endpoint{
+		{
+			Path:        "/users/info",
+			Method:      "GET",
+			QueryParams: []model.Field{{Name: "user_id", Types: []string{"String", "UUID"}}},
+			Headers:     []model.Field{{Name: "Authorization", Types: []string{"String", "Auth-Token"}, Required: true}},
+		},
+		{
+			Path:   "/users/create",
+			Method: "POST",
+			Body: []model.Field{
+				{
+					Name:     "address",
+					Types:    []string{"Object"},
+					Required: true,
+					Properties: []model.Field{
+						{Name: "city", Types: []string{"String"}, Required: true},
+					},
+				},
+				{Name: "age", Types: []string{"Int"}, Constraints: &model.Constraints{Minimum: "0", ExclusiveMaximum: "150", MultipleOf: "1"}},
+				{
+					Name:  "code",
+					Types: []string{"Int", "String"},
+					AllOf: []model.Field{{Types: []string{"String", "UUID"}}},
+					Not:   &model.Field{Types: []string{"Int", "Boolean"}},
+				},
+				{
+					Name:  "contact",
+					AnyOf: []model.Field{{Types: []string{"String", "Email"}}, {Types: []string{"Int"}}},
+				},
+				{Name: "dob", Types: []string{"Date"}},
+				{Name: "id", Types: []string{"Int", "String"}},
+				{
+					Name:        "levels",
+					Types:       []string{"List"},
+					Items:       &model.Field{Types: []string{"Decimal"}, Constraints: &model.Constraints{Enum: []interface{}{json.Number("1"), json.Number("2.5")}}},
+					Constraints: &model.Constraints{MinItems: &two, MaxItems: &eight, UniqueItems: true},
+				},
+				{
+					Name:        "nick",
+					Types:       []string{"String"},
+					Constraints: &model.Constraints{MinLength: &two, MaxLength: &eight, Pattern: "^[a-z]+$", Enum: []interface{}{"ann", "bob"}},
+				},
+			},
+		},
+		{
+			Path:        "/users/upload",
+			Method:      "POST",
+			ContentType: "multipart/form-data",
+			Body: []model.Field{{
+				Name:        "avatar",
+				Types:       []string{"File"},
+				Required:    true,
+				Constraints: &model.Constraints{MaxSize: &maxSize, MediaTypes: []string{"image/*"}},
+			}},
+		},
+	}
+
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	require.NoError(t, model.StoreModels(db, endpoints, "test"))
+	endpointModels, err := model.GetModels(db)
+	require.NoError(t, err)
+
+	exported := Export(endpointModels)
+	require.Contains(t, exported.Paths["/users/upload"].Post.RequestBody.Content, "multipart/form-data")
+
+	var yamlDocument bytes.Buffer
+	require.NoError(t, exported.EncodeYAML(&yamlDocument))
+	// numbers are written as numbers, not strings
+	require.Contains(t, yamlDocument.String(), "maximum: 150\n")
+	require.Contains(t, yamlDocument.String(), "- 2.5\n")
+
+	doc, err := Parse(&yamlDocument)
+	require.NoError(t, err)
+
+	imported, warnings := Import(doc)
+	require.Empty(t, warnings)
+	require.Equal(t, []model.Endpoint{endpoints[1], endpoints[0], endpoints[2]}, imported)
+}
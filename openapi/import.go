@@ -0,0 +1,435 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evgeniron/API-Validator/model"
+)
+
+// Warning reports an OpenAPI construct that could not be translated into the model
+type Warning struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+// formatTypes maps OpenAPI string formats onto the validator type names. The
+// "date" format is an RFC 3339 full-date, unlike the dd-mm-yyyy Date type.
+var formatTypes = map[string]string{
+	"uuid":  "UUID",
+	"email": "Email",
+	"date":  "ISODate",
+}
+
+// integerFormatTypes maps OpenAPI integer formats onto the validator type names
+var integerFormatTypes = map[string]string{
+	"int32": "Int32",
+	"int64": "Int64",
+}
+
+type importer struct {
+	doc       *Document
+	resolving map[string]bool
+	warnings  []Warning
+}
+
+// Import converts every operation of the document into an endpoint model.
+// Constructs that cannot be expressed by the model are skipped and reported as warnings.
+func Import(doc *Document) ([]model.Endpoint, []Warning) {
+	im := &importer{doc: doc, resolving: make(map[string]bool)}
+
+	var endpoints []model.Endpoint
+	for _, path := range sortedKeys(doc.Paths) {
+		item := doc.Paths[path]
+		if item == nil {
+			continue
+		}
+
+		operations := item.operations()
+		for _, method := range sortedKeys(operations) {
+			endpoints = append(endpoints, im.endpoint(path, method, item, operations[method]))
+		}
+	}
+	return endpoints, im.warnings
+}
+
+func (im *importer) warn(location, format string, args ...interface{}) {
+	im.warnings = append(im.warnings, Warning{Location: location, Message: fmt.Sprintf(format, args...)})
+}
+
+func (im *importer) endpoint(path, method string, item *PathItem, operation *Operation) model.Endpoint {
+	location := method + " " + path
+	endpoint := model.Endpoint{Path: path, Method: method}
+
+	for _, parameter := range im.parameters(location, item.Parameters, operation.Parameters) {
+		switch parameter.In {
+		case "path":
+			// path parameters are always required by the specification
+			field := im.field(location+" path_params", parameter.Name, parameter.Schema, true)
+			endpoint.PathParams = append(endpoint.PathParams, field)
+		case "query":
+			field := im.field(location+" query_params", parameter.Name, parameter.Schema, parameter.Required)
+			endpoint.QueryParams = append(endpoint.QueryParams, field)
+		case "header":
+			field := im.field(location+" headers", parameter.Name, parameter.Schema, parameter.Required)
+			endpoint.Headers = append(endpoint.Headers, field)
+		default:
+			im.warn(location, "parameter %q in %q is not supported", parameter.Name, parameter.In)
+		}
+	}
+
+	endpoint.Body, endpoint.ContentType = im.body(location, operation.RequestBody)
+	return endpoint
+}
+
+// parameters resolves the path item and operation parameters, where an operation
+// parameter overrides the path item parameter with the same name and location
+func (im *importer) parameters(location string, itemParameters, operationParameters []*Parameter) []*Parameter {
+	var parameters []*Parameter
+	index := make(map[string]int)
+	for _, parameter := range append(append([]*Parameter{}, itemParameters...), operationParameters...) {
+		parameter = im.resolveParameter(location, parameter)
+		if parameter == nil {
+			continue
+		}
+
+		key := parameter.In + ":" + parameter.Name
+		if i, exists := index[key]; exists {
+			parameters[i] = parameter
+			continue
+		}
+		index[key] = len(parameters)
+		parameters = append(parameters, parameter)
+	}
+	return parameters
+}
+
+func (im *importer) resolveParameter(location string, parameter *Parameter) *Parameter {
+	if parameter == nil || parameter.Ref == "" {
+		return parameter
+	}
+
+	name, ok := componentName(parameter.Ref, "parameters")
+	if ok && im.doc.Components != nil && im.doc.Components.Parameters[name] != nil {
+		return im.doc.Components.Parameters[name]
+	}
+	im.warn(location, "unresolved parameter reference %q", parameter.Ref)
+	return nil
+}
+
+// body translates the JSON content of the request body. Without JSON content, the
+// form, multipart or XML content is translated and its media type returned as the
+// content type of the endpoint.
+func (im *importer) body(location string, requestBody *RequestBody) ([]model.Field, string) {
+	if requestBody != nil && requestBody.Ref != "" {
+		ref := requestBody.Ref
+		name, ok := componentName(ref, "requestBodies")
+		requestBody = nil
+		if ok && im.doc.Components != nil {
+			requestBody = im.doc.Components.RequestBodies[name]
+		}
+		if requestBody == nil {
+			im.warn(location, "unresolved request body reference %q", ref)
+			return nil, ""
+		}
+	}
+
+	if requestBody == nil || len(requestBody.Content) == 0 {
+		return nil, ""
+	}
+
+	var contentType string
+	mediaType := jsonMediaType(requestBody.Content)
+	if mediaType == nil {
+		contentType, mediaType = fieldsMediaType(requestBody.Content)
+	}
+	if mediaType == nil {
+		im.warn(location, "request body content types %v are not supported", sortedKeys(requestBody.Content))
+		return nil, ""
+	}
+
+	body := im.field(location+" body", "", mediaType.Schema, requestBody.Required)
+	if body.Properties == nil && mediaType.Schema != nil && (len(body.Types) != 1 || body.Types[0] != "Object") {
+		im.warn(location, "request body schema must be an object")
+	}
+	return body.Properties, contentType
+}
+
+// field translates a schema into a field, resolving local references and walking
+// into object properties and list items
+func (im *importer) field(location, name string, schema *Schema, required bool) model.Field {
+	field := model.Field{Name: name, Required: required}
+	if name != "" {
+		location += "/" + name
+	}
+
+	im.describe(location, &field, schema)
+	return field
+}
+
+func (im *importer) describe(location string, field *model.Field, schema *Schema) {
+	if schema == nil {
+		im.warn(location, "missing schema, value is not validated")
+		return
+	}
+
+	if schema.Ref != "" {
+		if im.resolving[schema.Ref] {
+			im.warn(location, "recursive schema %q is not supported", schema.Ref)
+			return
+		}
+
+		resolved := im.resolveSchema(schema.Ref)
+		if resolved == nil {
+			im.warn(location, "unresolved schema reference %q", schema.Ref)
+			return
+		}
+
+		im.resolving[schema.Ref] = true
+		defer delete(im.resolving, schema.Ref)
+		im.describe(location, field, resolved)
+		return
+	}
+
+	// documents exported by the validator already carry the validator type names
+	if schema.XTypes != nil {
+		defer func() { field.Types = append([]string{}, schema.XTypes...) }()
+	}
+	im.combinators(location, field, schema)
+	field.Constraints = im.constraints(location, schema)
+
+	switch schema.Type {
+	case "string":
+		field.Types = []string{"String"}
+		if schema.Format == "" {
+			break
+		}
+		if formatType, ok := formatTypes[schema.Format]; ok {
+			field.Types = append(field.Types, formatType)
+		} else {
+			im.warn(location, "string format %q is not supported, validated as String", schema.Format)
+		}
+	case "integer":
+		field.Types = []string{"Int"}
+		if integerType, ok := integerFormatTypes[schema.Format]; ok {
+			field.Types = []string{integerType}
+		}
+	case "number":
+		// a number without a format has no precision limit
+		field.Types = []string{"Decimal"}
+		if schema.Format == "float" || schema.Format == "double" {
+			field.Types = []string{"Float"}
+		}
+	case "boolean":
+		field.Types = []string{"Boolean"}
+	case "array":
+		field.Types = []string{"List"}
+		if schema.Items != nil {
+			items := im.field(location+"/items", "", schema.Items, false)
+			field.Items = &items
+		}
+	case "object", "":
+		if schema.Type == "object" {
+			field.Types = []string{"Object"}
+		}
+		if schema.Properties == nil {
+			break
+		}
+
+		requiredProperties := make(map[string]bool, len(schema.Required))
+		for _, property := range schema.Required {
+			requiredProperties[property] = true
+		}
+
+		field.Properties = []model.Field{}
+		for _, property := range sortedKeys(schema.Properties) {
+			field.Properties = append(field.Properties, im.field(location, property, schema.Properties[property], requiredProperties[property]))
+		}
+	default:
+		im.warn(location, "type %q is not supported", schema.Type)
+	}
+}
+
+// combinators translates the schema combinators into field branches. Documents
+// exported by the validator also list conflicting types in allOf, these entries
+// are already described by x-types and skipped.
+func (im *importer) combinators(location string, field *model.Field, schema *Schema) {
+	for i, branch := range schema.AllOf {
+		if schema.XTypes != nil && isTypeListing(branch) {
+			continue
+		}
+		field.AllOf = append(field.AllOf, im.field(fmt.Sprintf("%s/allOf/%d", location, i), "", branch, false))
+	}
+	for i, branch := range schema.AnyOf {
+		field.AnyOf = append(field.AnyOf, im.field(fmt.Sprintf("%s/anyOf/%d", location, i), "", branch, false))
+	}
+	for i, branch := range schema.OneOf {
+		field.OneOf = append(field.OneOf, im.field(fmt.Sprintf("%s/oneOf/%d", location, i), "", branch, false))
+	}
+	if schema.Not != nil {
+		not := im.field(location+"/not", "", schema.Not, false)
+		field.Not = &not
+	}
+}
+
+// constraints translates the constraint keywords of the schema, nil when it has
+// none. Exclusive bounds are read both as the flags of OpenAPI 3.0 and as the
+// numbers of OpenAPI 3.1. The pattern of an exported type, e.g. Date, is left to
+// the type.
+func (im *importer) constraints(location string, schema *Schema) *model.Constraints {
+	constraints := model.Constraints{
+		Minimum:     im.number(location, "minimum", schema.Minimum),
+		Maximum:     im.number(location, "maximum", schema.Maximum),
+		MultipleOf:  im.number(location, "multipleOf", schema.MultipleOf),
+		MinLength:   schema.MinLength,
+		MaxLength:   schema.MaxLength,
+		Pattern:     schema.Pattern,
+		MinItems:    schema.MinItems,
+		MaxItems:    schema.MaxItems,
+		UniqueItems: schema.UniqueItems,
+		MaxSize:     schema.XMaxSize,
+		MediaTypes:  schema.XMediaTypes,
+	}
+
+	constraints.Minimum, constraints.ExclusiveMinimum = im.exclusiveBound(location, "exclusiveMinimum", constraints.Minimum, schema.ExclusiveMinimum)
+	constraints.Maximum, constraints.ExclusiveMaximum = im.exclusiveBound(location, "exclusiveMaximum", constraints.Maximum, schema.ExclusiveMaximum)
+
+	for _, typeName := range schema.XTypes {
+		if typeSchema, ok := typeSchemas[typeName]; ok && typeSchema.Pattern == constraints.Pattern {
+			constraints.Pattern = ""
+		}
+	}
+
+	for _, value := range schema.Enum {
+		constraints.Enum = append(constraints.Enum, importValue(value))
+	}
+
+	if reflect.DeepEqual(constraints, model.Constraints{}) {
+		return nil
+	}
+	return &constraints
+}
+
+// exclusiveBound returns the inclusive and exclusive bound of an OpenAPI 3.0
+// bound and its exclusive flag, or of an OpenAPI 3.1 exclusive bound
+func (im *importer) exclusiveBound(location, keyword string, bound json.Number, exclusive interface{}) (json.Number, json.Number) {
+	switch flag := exclusive.(type) {
+	case nil:
+		return bound, ""
+	case bool:
+		if flag {
+			return "", bound
+		}
+		return bound, ""
+	}
+
+	if number, ok := importValue(exclusive).(json.Number); ok {
+		return bound, im.number(location, keyword, Number(number))
+	}
+	im.warn(location, "%s %v is not supported", keyword, exclusive)
+	return bound, ""
+}
+
+// number returns the literal of a numeric keyword, literals that aren't JSON
+// numbers, e.g. 0x1F, are dropped with a warning
+func (im *importer) number(location, keyword string, value Number) json.Number {
+	if value == "" || model.NumberPattern.MatchString(string(value)) {
+		return json.Number(value)
+	}
+	im.warn(location, "%s %q is not a number", keyword, value)
+	return ""
+}
+
+// importValue converts the numbers of a decoded YAML value to json.Number, as
+// numbers are decoded in models
+func importValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return json.Number(strconv.Itoa(v))
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10))
+	case uint64:
+		return json.Number(strconv.FormatUint(v, 10))
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64))
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = importValue(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = importValue(item)
+		}
+		return object
+	}
+	return value
+}
+
+// isTypeListing reports whether the schema only names a type
+func isTypeListing(schema *Schema) bool {
+	return reflect.DeepEqual(*schema, Schema{Type: schema.Type, Format: schema.Format, Pattern: schema.Pattern})
+}
+
+func (im *importer) resolveSchema(ref string) *Schema {
+	name, ok := componentName(ref, "schemas")
+	if !ok || im.doc.Components == nil {
+		return nil
+	}
+	return im.doc.Components.Schemas[name]
+}
+
+// componentName extracts the component name from a local reference such as
+// "#/components/schemas/Pet"
+func componentName(ref, kind string) (string, bool) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, prefix), true
+}
+
+func jsonMediaType(content map[string]*MediaType) *MediaType {
+	for _, contentType := range sortedKeys(content) {
+		mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			if content[contentType] == nil {
+				return &MediaType{}
+			}
+			return content[contentType]
+		}
+	}
+	return nil
+}
+
+// fieldsMediaType returns the first form, multipart or XML content, the other
+// formats whose bodies the validator reads into fields
+func fieldsMediaType(content map[string]*MediaType) (string, *MediaType) {
+	for _, contentType := range sortedKeys(content) {
+		mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+		switch {
+		case mediaType == "application/x-www-form-urlencoded", mediaType == "multipart/form-data",
+			mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+			if content[contentType] == nil {
+				return contentType, &MediaType{}
+			}
+			return contentType, content[contentType]
+		}
+	}
+	return "", nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
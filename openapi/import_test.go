@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		document    string
+		expectedErr bool
+	}{
+		{
+			name:     "YAML document",
+			document: "openapi: 3.0.0\ninfo:\n  title: test\n  version: '1'\npaths: {}\n",
+		},
+		{
+			name:     "JSON document",
+			document: `{"openapi": "3.1.0", "info": {"title": "test", "version": "1"}, "paths": {}}`,
+		},
+		{
+			name:        "Swagger 2 document",
+			document:    `{"swagger": "2.0", "paths": {}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "Malformed document",
+			document:    `{"openapi": `,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.document))
+			if tt.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	file, err := os.Open("test_data/petstore.yaml")
+	require.NoError(t, err)
+	defer file.Close()
+
+	doc, err := Parse(file)
+	require.NoError(t, err)
+
+	endpoints, warnings := Import(doc)
+
+	authorization := model.Field{Name: "Authorization", Types: []string{"String"}, Required: true}
+	petID := model.Field{Name: "petId", Types: []string{"String", "UUID"}, Required: true}
+	require.Equal(t, []model.Endpoint{
+		{
+			Path:   "/pets",
+			Method: "GET",
+			QueryParams: []model.Field{
+				{Name: "limit", Types: []string{"Int32"}},
+			},
+			Headers: []model.Field{authorization},
+		},
+		{
+			Path:    "/pets",
+			Method:  "POST",
+			Headers: []model.Field{authorization},
+			Body: []model.Field{
+				{Name: "birthday", Types: []string{"String", "ISODate"}},
+				{
+					Name: "contact",
+					OneOf: []model.Field{
+						{Types: []string{"String", "Email"}},
+						{Types: []string{"Int64"}},
+					},
+				},
+				{Name: "name", Types: []string{"String"}, Required: true},
+				{
+					Name:     "owner",
+					Types:    []string{"Object"},
+					Required: true,
+					Properties: []model.Field{
+						{Name: "email", Types: []string{"String", "Email"}, Required: true},
+					},
+				},
+				{
+					Name:  "tags",
+					Types: []string{"List"},
+					Items: &model.Field{Types: []string{"String"}},
+				},
+				{Name: "vaccinated", Types: []string{"Boolean"}},
+				{Name: "weight", Types: []string{"Decimal"}},
+			},
+		},
+		{
+			Path:       "/pets/{petId}",
+			Method:     "GET",
+			PathParams: []model.Field{petID},
+		},
+		{
+			Path:       "/pets/{petId}",
+			Method:     "PUT",
+			PathParams: []model.Field{petID},
+		},
+	}, endpoints)
+
+	require.Equal(t, []Warning{
+		{Location: "GET /pets", Message: `parameter "session" in "cookie" is not supported`},
+		{Location: "POST /pets body/tags/items", Message: `string format "hostname" is not supported, validated as String`},
+		{Location: "PUT /pets/{petId}", Message: "request body content types [image/png] are not supported"},
+	}, warnings)
+}
+
+func TestImportRecursiveSchema(t *testing.T) {
+	doc := &Document{
+		OpenAPI: "3.0.0",
+		Paths: map[string]*PathItem{
+			"/nodes": {
+				Post: &Operation{
+					RequestBody: &RequestBody{
+						Content: map[string]*MediaType{
+							"application/json": {Schema: &Schema{Ref: "#/components/schemas/Node"}},
+						},
+					},
+				},
+			},
+		},
+		Components: &Components{
+			Schemas: map[string]*Schema{
+				"Node": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"child": {Ref: "#/components/schemas/Node"},
+					},
+				},
+			},
+		},
+	}
+
+	endpoints, warnings := Import(doc)
+	require.Len(t, endpoints, 1)
+	require.Equal(t, []model.Field{{Name: "child"}}, endpoints[0].Body)
+	require.Equal(t, []Warning{
+		{Location: "POST /nodes body/child", Message: `recursive schema "#/components/schemas/Node" is not supported`},
+	}, warnings)
+}
+
+func TestImportInvalidNumbers(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`
+openapi: 3.1.0
+paths:
+  /items:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                count:
+                  type: integer
+                  minimum: abc
+                  maximum: 10
+                  multipleOf: 0x1F
+                  exclusiveMinimum: .nan
+`))
+	require.NoError(t, err)
+
+	endpoints, warnings := Import(doc)
+	require.Len(t, endpoints, 1)
+	require.Equal(t, []model.Field{
+		{Name: "count", Types: []string{"Int"}, Constraints: &model.Constraints{Maximum: "10"}},
+	}, endpoints[0].Body)
+	require.Equal(t, []Warning{
+		{Location: "POST /items body/count", Message: `minimum "abc" is not a number`},
+		{Location: "POST /items body/count", Message: `multipleOf "0x1F" is not a number`},
+		{Location: "POST /items body/count", Message: `exclusiveMinimum "NaN" is not a number`},
+	}, warnings)
+}
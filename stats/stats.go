@@ -0,0 +1,287 @@
+/*
+Package stats aggregates validation reports, so a model can be tuned on how often
+the traffic violates it before it's enforced.
+
+Reports are counted per endpoint model, and their errors per section, field
+pointer and error type, into time buckets. List indexes in the pointers are
+counted as "*", as are the names of unrecognized fields, so the pointers are
+bounded by the model rather than by the traffic, and a bucket counts at most
+maxErrorKeys distinct errors. The counts of a window sum the buckets
+overlapping it, so windows slide by one bucket at a time, and buckets older than
+the longest window are dropped.
+*/
+package stats
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evgeniron/API-Validator/validate"
+)
+
+// Window is a sliding time window the counts are reported for
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+type Options struct {
+	// Bucket is the time resolution of the counters
+	Bucket  time.Duration
+	Windows []Window
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Bucket: time.Minute,
+		Windows: []Window{
+			{Name: "5m", Duration: 5 * time.Minute},
+			{Name: "1h", Duration: time.Hour},
+			{Name: "24h", Duration: 24 * time.Hour},
+		},
+	}
+}
+
+// Recorder counts the validation reports, it's safe for concurrent use
+type Recorder struct {
+	mu        sync.Mutex
+	options   Options
+	retention time.Duration
+	now       func() time.Time
+	endpoints map[string]*endpointCounters
+}
+
+func NewRecorder(options Options) *Recorder {
+	r := &Recorder{options: options, now: time.Now, endpoints: make(map[string]*endpointCounters)}
+	for _, window := range options.Windows {
+		if window.Duration > r.retention {
+			r.retention = window.Duration
+		}
+	}
+	return r
+}
+
+// maxErrorKeys bounds the distinct errors counted in a bucket, further errors are
+// counted under OtherErrors
+const maxErrorKeys = 1000
+
+// OtherErrors is the error type of the errors beyond maxErrorKeys, counted per section
+const OtherErrors = "other errors"
+
+type errorKey struct {
+	section   string
+	path      string
+	errorType string
+}
+
+type bucket struct {
+	start    time.Time
+	requests int
+	invalid  int
+	errors   map[errorKey]int
+}
+
+type endpointCounters struct {
+	path   string
+	method string
+	// buckets are ordered from the oldest
+	buckets []*bucket
+}
+
+// EndpointStats are the counts of an endpoint model in every window
+type EndpointStats struct {
+	Path    string        `json:"path"`
+	Method  string        `json:"method"`
+	Windows []WindowStats `json:"windows"`
+}
+
+type WindowStats struct {
+	Window   string       `json:"window"`
+	Requests int          `json:"requests"`
+	Invalid  int          `json:"invalid"`
+	Errors   []ErrorStats `json:"errors"`
+}
+
+// ErrorStats counts an error type of a field. Path is the JSON pointer of the field
+// in its report section, as in validate.ValidationError.
+type ErrorStats struct {
+	Section   string `json:"section"`
+	Path      string `json:"path"`
+	ErrorType string `json:"error_type"`
+	Count     int    `json:"count"`
+}
+
+// Record counts the report of a request validated against the model with the path
+// and method. The model path is used rather than the request path, so requests
+// matching a path template are counted together.
+func (r *Recorder) Record(path, method string, report *validate.ValidationReport) {
+	if r == nil || report == nil {
+		return
+	}
+
+	now := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := method + " " + path
+	counters, ok := r.endpoints[key]
+	if !ok {
+		counters = &endpointCounters{path: path, method: method}
+		r.endpoints[key] = counters
+	}
+	counters.prune(now, r.retention, r.options.Bucket)
+
+	start := now.Truncate(r.options.Bucket)
+	if n := len(counters.buckets); n == 0 || !counters.buckets[n-1].start.Equal(start) {
+		counters.buckets = append(counters.buckets, &bucket{start: start, errors: make(map[errorKey]int)})
+	}
+	b := counters.buckets[len(counters.buckets)-1]
+
+	b.requests++
+	if !report.Valid {
+		b.invalid++
+	}
+	sections := []struct {
+		name   string
+		errors []validate.ValidationError
+	}{
+		{"path_params", report.PathParams},
+		{"query_params", report.QueryParams},
+		{"headers", report.Headers},
+		{"body", report.Body},
+		{"rules", report.Rules},
+	}
+	for _, section := range sections {
+		for _, validationError := range section.errors {
+			key := errorKey{section: section.name, path: normalizePath(validationError), errorType: validationError.ErrorType}
+			if _, counted := b.errors[key]; !counted && len(b.errors) >= maxErrorKeys {
+				key = errorKey{section: section.name, path: "*", errorType: OtherErrors}
+			}
+			b.errors[key]++
+		}
+	}
+}
+
+// normalizePath replaces the list indexes of the error pointer and the name of an
+// unrecognized field with "*"
+func normalizePath(validationError validate.ValidationError) string {
+	if validationError.Path == "" {
+		return ""
+	}
+
+	segments := strings.Split(validationError.Path, "/")
+	for i, segment := range segments[1:] {
+		if isIndex(segment) {
+			segments[i+1] = "*"
+		}
+	}
+	if validationError.ErrorType == validate.ErrUnrecognizedField {
+		segments[len(segments)-1] = "*"
+	}
+	return strings.Join(segments, "/")
+}
+
+func isIndex(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, c := range segment {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// prune drops the buckets that ended before the retention
+func (ec *endpointCounters) prune(now time.Time, retention, bucketSize time.Duration) {
+	cutoff := now.Add(-retention)
+	i := 0
+	for i < len(ec.buckets) && !ec.buckets[i].start.Add(bucketSize).After(cutoff) {
+		i++
+	}
+	ec.buckets = ec.buckets[i:]
+}
+
+// Stats returns the counts of the endpoints whose model path starts with the
+// path prefix and whose method matches, an empty method matches any. Endpoints
+// are sorted by path and method, and errors from the most frequent.
+func (r *Recorder) Stats(pathPrefix, method string) []EndpointStats {
+	now := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := []EndpointStats{}
+	for key, counters := range r.endpoints {
+		if !strings.HasPrefix(counters.path, pathPrefix) || (method != "" && !strings.EqualFold(counters.method, method)) {
+			continue
+		}
+		counters.prune(now, r.retention, r.options.Bucket)
+		if len(counters.buckets) == 0 {
+			delete(r.endpoints, key)
+			continue
+		}
+
+		endpointStats := EndpointStats{Path: counters.path, Method: counters.method}
+		for _, window := range r.options.Windows {
+			endpointStats.Windows = append(endpointStats.Windows, counters.window(now, window, r.options.Bucket))
+		}
+		stats = append(stats, endpointStats)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Path != stats[j].Path {
+			return stats[i].Path < stats[j].Path
+		}
+		return stats[i].Method < stats[j].Method
+	})
+	return stats
+}
+
+func (ec *endpointCounters) window(now time.Time, window Window, bucketSize time.Duration) WindowStats {
+	windowStats := WindowStats{Window: window.Name, Errors: []ErrorStats{}}
+	cutoff := now.Add(-window.Duration)
+	counts := make(map[errorKey]int)
+	for _, b := range ec.buckets {
+		if !b.start.Add(bucketSize).After(cutoff) {
+			continue
+		}
+		windowStats.Requests += b.requests
+		windowStats.Invalid += b.invalid
+		for key, count := range b.errors {
+			counts[key] += count
+		}
+	}
+
+	for key, count := range counts {
+		windowStats.Errors = append(windowStats.Errors, ErrorStats{Section: key.section, Path: key.path, ErrorType: key.errorType, Count: count})
+	}
+	sort.Slice(windowStats.Errors, func(i, j int) bool {
+		a, b := windowStats.Errors[i], windowStats.Errors[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Section != b.Section {
+			return a.Section < b.Section
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.ErrorType < b.ErrorType
+	})
+	return windowStats
+}
+
+// Reset drops every count
+func (r *Recorder) Reset() {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.endpoints = make(map[string]*endpointCounters)
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/evgeniron/API-Validator/validate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	now := time.Date(2023, 2, 1, 12, 0, 30, 0, time.UTC)
+	recorder := NewRecorder(Options{
+		Bucket: time.Minute,
+		Windows: []Window{
+			{Name: "5m", Duration: 5 * time.Minute},
+			{Name: "1h", Duration: time.Hour},
+		},
+	})
+	recorder.now = func() time.Time { return now }
+
+	invalid := validate.NewValidationReport().
+		WithQueryParams([]validate.ValidationError{{FieldName: "limit", Path: "/limit", ErrorType: validate.ErrMismatchType}}).
+		WithBody([]validate.ValidationError{
+			{FieldName: "city", Path: "/address/city", ErrorType: validate.ErrMissingRequiredField},
+			{FieldName: "extra", Path: "/extra", ErrorType: validate.ErrUnrecognizedField},
+		}).IsValid()
+	valid := validate.NewValidationReport().IsValid()
+	rules := validate.NewValidationReport().
+		WithRules([]validate.ValidationError{{FieldName: "dates", Path: "/dates", ErrorType: validate.ErrRuleFailed}}).IsValid()
+
+	// 30 minutes ago, only in the 1h window
+	now = now.Add(-30 * time.Minute)
+	recorder.Record("/users/{id}", "GET", invalid)
+	recorder.Record("/users/{id}", "GET", invalid)
+	now = now.Add(30 * time.Minute)
+
+	recorder.Record("/users/{id}", "GET", invalid)
+	recorder.Record("/users/{id}", "GET", valid)
+	recorder.Record("/bookings", "POST", rules)
+	recorder.Record("/bookings", "POST", nil)
+
+	expected := []EndpointStats{
+		{Path: "/bookings", Method: "POST", Windows: []WindowStats{
+			{Window: "5m", Requests: 1, Invalid: 1, Errors: []ErrorStats{{Section: "rules", Path: "/dates", ErrorType: validate.ErrRuleFailed, Count: 1}}},
+			{Window: "1h", Requests: 1, Invalid: 1, Errors: []ErrorStats{{Section: "rules", Path: "/dates", ErrorType: validate.ErrRuleFailed, Count: 1}}},
+		}},
+		{Path: "/users/{id}", Method: "GET", Windows: []WindowStats{
+			{Window: "5m", Requests: 2, Invalid: 1, Errors: []ErrorStats{
+				{Section: "body", Path: "/*", ErrorType: validate.ErrUnrecognizedField, Count: 1},
+				{Section: "body", Path: "/address/city", ErrorType: validate.ErrMissingRequiredField, Count: 1},
+				{Section: "query_params", Path: "/limit", ErrorType: validate.ErrMismatchType, Count: 1},
+			}},
+			{Window: "1h", Requests: 4, Invalid: 3, Errors: []ErrorStats{
+				{Section: "body", Path: "/*", ErrorType: validate.ErrUnrecognizedField, Count: 3},
+				{Section: "body", Path: "/address/city", ErrorType: validate.ErrMissingRequiredField, Count: 3},
+				{Section: "query_params", Path: "/limit", ErrorType: validate.ErrMismatchType, Count: 3},
+			}},
+		}},
+	}
+	require.Equal(t, expected, recorder.Stats("", ""))
+	require.Equal(t, expected[1:], recorder.Stats("/users", "get"))
+	require.Empty(t, recorder.Stats("", "DELETE"))
+
+	// the windows slide, the older requests leave the 1h window
+	now = now.Add(45 * time.Minute)
+	stats := recorder.Stats("/users", "")
+	require.Len(t, stats, 1)
+	require.Equal(t, WindowStats{Window: "5m", Errors: []ErrorStats{}}, stats[0].Windows[0])
+	require.Equal(t, 2, stats[0].Windows[1].Requests)
+
+	// and are dropped once they're older than the longest window
+	now = now.Add(time.Hour)
+	require.Empty(t, recorder.Stats("", ""))
+
+	recorder.Record("/users/{id}", "GET", valid)
+	recorder.Reset()
+	require.Empty(t, recorder.Stats("", ""))
+}
+
+func TestRecorderErrorKeys(t *testing.T) {
+	recorder := NewRecorder(DefaultOptions())
+
+	var errors []validate.ValidationError
+	for i := 0; i < 3; i++ {
+		errors = append(errors,
+			validate.ValidationError{Path: "/items/" + strconv.Itoa(i) + "/id", ErrorType: validate.ErrMismatchType},
+			validate.ValidationError{Path: "/items/" + strconv.Itoa(i) + "/extra" + strconv.Itoa(i), ErrorType: validate.ErrUnrecognizedField},
+		)
+	}
+	recorder.Record("/orders", "POST", validate.NewValidationReport().WithBody(errors).IsValid())
+
+	require.Equal(t, []ErrorStats{
+		{Section: "body", Path: "/items/*/*", ErrorType: validate.ErrUnrecognizedField, Count: 3},
+		{Section: "body", Path: "/items/*/id", ErrorType: validate.ErrMismatchType, Count: 3},
+	}, recorder.Stats("", "")[0].Windows[0].Errors)
+
+	// distinct errors beyond the cap are counted together
+	errors = nil
+	for i := 0; i < maxErrorKeys+5; i++ {
+		errors = append(errors, validate.ValidationError{Path: "/items", ErrorType: "error " + strconv.Itoa(i)})
+	}
+	recorder.Reset()
+	recorder.Record("/orders", "POST", validate.NewValidationReport().WithBody(errors).IsValid())
+
+	counts := recorder.Stats("", "")[0].Windows[0].Errors
+	require.Len(t, counts, maxErrorKeys+1)
+	require.Equal(t, ErrorStats{Section: "body", Path: "*", ErrorType: OtherErrors, Count: 5}, counts[0])
+}
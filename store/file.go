@@ -0,0 +1,172 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+/*
+The file store keeps the same copy-on-write snapshots as the in-memory store and writes every new snapshot
+to a single JSON file before publishing it. The file is written to a temporary file in the same directory,
+synced and atomically renamed over the previous file, so a crash mid-write leaves the previous snapshot intact.
+
+Records are stored together with the name of their kind, so they decode back into their Go type. The kinds
+are given by the caller, the store knows nothing of the records it keeps.
+*/
+
+const fileFormatVersion = 1
+
+// Kinds maps the kind names of the records a file store keeps onto a record of
+// each kind, e.g. {"endpoint_model": &EndpointModel{}}
+type Kinds map[string]interface{}
+
+// registry resolves record types to kind names and back
+type registry struct {
+	kinds map[reflect.Type]string
+	types map[string]reflect.Type
+}
+
+func newRegistry(kinds Kinds) *registry {
+	r := &registry{kinds: make(map[reflect.Type]string, len(kinds)), types: make(map[string]reflect.Type, len(kinds))}
+	for name, record := range kinds {
+		recordType := reflect.TypeOf(record)
+		r.kinds[recordType] = name
+		r.types[name] = recordType
+	}
+	return r
+}
+
+type fileRecord struct {
+	Kind   string          `json:"kind"`
+	Record json.RawMessage `json:"record"`
+}
+
+type fileSnapshot struct {
+	Version int                   `json:"version"`
+	Records map[string]fileRecord `json:"records"`
+}
+
+// NewFileDB opens the store persisted at path, loading every record written by a previous run.
+// The file is created on the first write when it does not exist. Only records of the given kinds
+// can be stored.
+func NewFileDB(path string, kinds Kinds) (*Store, error) {
+	r := newRegistry(kinds)
+	db, err := readSnapshotFile(path, r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		persist: func(db inMemory) error {
+			return writeSnapshotFile(path, db, r)
+		},
+	}
+	s.snapshot.Store(&Snapshot{db: db})
+	return s, nil
+}
+
+func readSnapshotFile(path string, r *registry) (inMemory, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(inMemory, 10), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading store file: %w", err)
+	}
+
+	var snapshot fileSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("error decoding store file: %w", err)
+	}
+	if snapshot.Version != fileFormatVersion {
+		return nil, fmt.Errorf("unsupported store file version %d", snapshot.Version)
+	}
+
+	db := make(inMemory, len(snapshot.Records))
+	for key, record := range snapshot.Records {
+		recordType, ok := r.types[record.Kind]
+		if !ok {
+			return nil, fmt.Errorf("unregistered record kind %q for key %s", record.Kind, key)
+		}
+
+		// numbers are kept as json.Number, as they are when records are inserted
+		// from decoded requests
+		value := reflect.New(recordType)
+		decoder := json.NewDecoder(bytes.NewReader(record.Record))
+		decoder.UseNumber()
+		if err := decoder.Decode(value.Interface()); err != nil {
+			return nil, fmt.Errorf("error decoding record %s: %w", key, err)
+		}
+		db[key] = value.Elem().Interface()
+	}
+	return db, nil
+}
+
+func writeSnapshotFile(path string, db inMemory, r *registry) error {
+	snapshot := fileSnapshot{Version: fileFormatVersion, Records: make(map[string]fileRecord, len(db))}
+
+	for key, record := range db {
+		kind, ok := r.kinds[reflect.TypeOf(record)]
+		if !ok {
+			return fmt.Errorf("unregistered record type %T for key %s", record, key)
+		}
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("error encoding record %s: %w", key, err)
+		}
+		snapshot.Records[key] = fileRecord{Kind: kind, Record: data}
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "\t")
+	if err != nil {
+		return fmt.Errorf("error encoding store file: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic replaces the file at path with data, either completely or not at all
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating store file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing store file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error syncing store file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing store file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error replacing store file: %w", err)
+	}
+	return syncDir(dir)
+}
+
+// syncDir makes the rename durable by syncing the directory entry
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("error opening store directory: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("error syncing store directory: %w", err)
+	}
+	return nil
+}
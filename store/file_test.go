@@ -0,0 +1,81 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRecord struct {
+	Name  string
+	Count int
+}
+
+var testKinds = Kinds{"test_record": &testRecord{}, "string": ""}
+
+func TestFileDBReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+
+	db, err := NewFileDB(path, testKinds)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Insert("record", &testRecord{Name: "test", Count: 1}))
+	require.NoError(t, db.InsertBatch(map[string]interface{}{"first": "one", "second": "two"}))
+	require.NoError(t, db.Delete("second"))
+
+	reloaded, err := NewFileDB(path, testKinds)
+	require.NoError(t, err)
+
+	record, err := reloaded.Get("record")
+	require.NoError(t, err)
+	require.Equal(t, &testRecord{Name: "test", Count: 1}, record)
+
+	first, err := reloaded.Get("first")
+	require.NoError(t, err)
+	require.Equal(t, "one", first)
+
+	_, err = reloaded.Get("second")
+	var e *RecordNotFoundError
+	require.ErrorAs(t, err, &e)
+}
+
+func TestFileDBFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "models.json")
+
+	db, err := NewFileDB(path, testKinds)
+	require.NoError(t, err)
+	require.NoError(t, db.Insert("first", "one"))
+
+	// unregistered types can't be persisted, the write must not change the store
+	require.Error(t, db.Insert("second", 2))
+	_, err = db.Get("second")
+	require.Error(t, err)
+
+	// a temporary file left behind by a crash mid-write doesn't affect the stored snapshot
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "models.json.tmp-123"), []byte(`{"version": 1, "rec`), 0o600))
+
+	reloaded, err := NewFileDB(path, testKinds)
+	require.NoError(t, err)
+	records, err := reloaded.List()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"one"}, records)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
+
+func TestFileDBCorrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 1, "rec`), 0o600))
+	_, err := NewFileDB(path, testKinds)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 1, "records": {"key": {"kind": "unknown", "record": 1}}}`), 0o600))
+	_, err = NewFileDB(path, testKinds)
+	require.Error(t, err)
+}
@@ -1,6 +1,10 @@
 package store
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
 
 /*
 In real life application I'd use document database. Document databases have better schema flexibility,
@@ -9,31 +13,122 @@ support for joins, many to one and many to many relationships.
 
 Our doucment is not deeply nested so it's not difficult to directly access it. Also, the whole document is required
 so there is a performance advantage to this storage locality (single lookup, no joins required)
+
+The store is copy-on-write: every write builds a new snapshot of the records and swaps it atomically, so
+readers never lock and a reader holding a snapshot keeps seeing a consistent set of records. Writes copy the
+whole map, which is cheap for the amount of models we keep and writes are rare compared to reads.
 */
 
 type inMemory map[string]interface{}
 
-type Store struct {
+// Snapshot is an immutable view of the store records at a point in time
+type Snapshot struct {
 	db inMemory
 }
 
+type Store struct {
+	// mu serializes writers, readers only load the current snapshot
+	mu       sync.Mutex
+	snapshot atomic.Pointer[Snapshot]
+
+	// persist durably writes a snapshot before it is published, nil for in-memory stores
+	persist func(db inMemory) error
+}
+
 func NewInMemoryDB() (*Store, error) {
-	db := make(inMemory, 10)
-	return &Store{db: db}, nil
+	s := &Store{}
+	s.snapshot.Store(&Snapshot{db: make(inMemory, 10)})
+	return s, nil
+}
+
+// Snapshot returns the current snapshot of the store
+func (s *Store) Snapshot() *Snapshot {
+	return s.snapshot.Load()
+}
+
+// update applies fn to a copy of the current records and publishes the copy as
+// the new snapshot. The snapshot is left untouched when fn fails.
+func (s *Store) update(fn func(db inMemory) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.snapshot.Load()
+	next := make(inMemory, len(current.db)+1)
+	for key, record := range current.db {
+		next[key] = record
+	}
+
+	if err := fn(next); err != nil {
+		return err
+	}
+
+	if s.persist != nil {
+		if err := s.persist(next); err != nil {
+			return err
+		}
+	}
+
+	s.snapshot.Store(&Snapshot{db: next})
+	return nil
 }
 
 func (s *Store) Insert(key string, model interface{}) error {
 	if key == "" {
 		return fmt.Errorf("empty key")
 	}
-	s.db[key] = model
-	return nil
+
+	return s.update(func(db inMemory) error {
+		db[key] = model
+		return nil
+	})
+}
+
+// InsertBatch inserts all records in a single snapshot, readers either see all of them or none
+func (s *Store) InsertBatch(records map[string]interface{}) error {
+	for key := range records {
+		if key == "" {
+			return fmt.Errorf("empty key")
+		}
+	}
+
+	return s.update(func(db inMemory) error {
+		for key, record := range records {
+			db[key] = record
+		}
+		return nil
+	})
 }
 
 func (s *Store) Get(key string) (interface{}, error) {
+	return s.Snapshot().Get(key)
+}
+
+func (s *Store) List() ([]interface{}, error) {
+	return s.Snapshot().List()
+}
+
+func (s *Store) Delete(key string) error {
+	return s.update(func(db inMemory) error {
+		if _, ok := db[key]; !ok {
+			return &RecordNotFoundError{Key: key}
+		}
+		delete(db, key)
+		return nil
+	})
+}
+
+func (s *Snapshot) Get(key string) (interface{}, error) {
 	record, ok := s.db[key]
 	if !ok {
 		return nil, &RecordNotFoundError{Key: key}
 	}
 	return record, nil
 }
+
+func (s *Snapshot) List() ([]interface{}, error) {
+	records := make([]interface{}, 0, len(s.db))
+	for _, record := range s.db {
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -67,3 +68,75 @@ func TestInsertAndGet(t *testing.T) {
 		}
 	}
 }
+
+func TestListAndDelete(t *testing.T) {
+	db, err := NewInMemoryDB()
+	require.NoError(t, err)
+
+	require.NoError(t, db.Insert("one", 1))
+	require.NoError(t, db.Insert("two", 2))
+
+	records, err := db.List()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []interface{}{1, 2}, records)
+
+	require.NoError(t, db.Delete("one"))
+	_, err = db.Get("one")
+	var e *RecordNotFoundError
+	require.ErrorAs(t, err, &e)
+
+	err = db.Delete("one")
+	require.ErrorAs(t, err, &e)
+
+	records, err = db.List()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{2}, records)
+}
+
+// TestConcurrentSnapshots checks that readers never observe a partially applied batch.
+// Run with -race to also detect unsynchronized access.
+func TestConcurrentSnapshots(t *testing.T) {
+	db, err := NewInMemoryDB()
+	require.NoError(t, err)
+	require.NoError(t, db.InsertBatch(map[string]interface{}{"first": 0, "second": 0}))
+
+	const writes = 200
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= writes; i++ {
+			if err := db.InsertBatch(map[string]interface{}{"first": i, "second": i}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+		}
+	}()
+
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				snapshot := db.Snapshot()
+				first, err := snapshot.Get("first")
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				second, err := snapshot.Get("second")
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if first != second {
+					t.Errorf("inconsistent snapshot: first %v, second %v", first, second)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Error(t, db.InsertBatch(map[string]interface{}{"": 1}))
+}
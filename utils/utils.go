@@ -6,8 +6,12 @@ import (
 	"net/http"
 )
 
+// Decode decodes the JSON request body into v. Numbers decoded into interface
+// values are kept as json.Number, so integers and decimals keep their exact value.
 func Decode(r *http.Request, v interface{}) error {
-	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
 		return fmt.Errorf("error decoding request body: %w", err)
 	}
 	return nil
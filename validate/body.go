@@ -0,0 +1,313 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const ErrContentTypeMismatch = "content type mismatch"
+
+// maxXMLDepth bounds the nesting of XML bodies
+const maxXMLDepth = 64
+
+// parseBody converts a body into fields by its content type:
+//
+//	JSON       the fields of a JSON object, other JSON values have none
+//	form       the form values
+//	multipart  the values of the parts, file parts become File values
+//	XML        the child elements of the root element, see parseXML
+//
+// Values of repeated form values, parts and elements are lists. wire is set for the
+// formats whose values are strings on the wire, all but JSON. Bodies without a
+// content type are taken as JSON, and bodies of other or invalid content types have
+// no fields.
+func parseBody(contentType string, body []byte) (fields []Field, wire bool, err error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, false, nil
+	}
+	if contentType == "" {
+		fields, err = parseJSON(body)
+		return fields, false, err
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false, nil
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		fields, err = parseJSON(body)
+		return fields, false, err
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, false, fmt.Errorf("error decoding body: %w", err)
+		}
+		return valuesFields(values), true, nil
+	case mediaType == "multipart/form-data":
+		fields, err = parseMultipart(body, params["boundary"])
+		return fields, true, err
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		fields, err = parseXML(body)
+		return fields, true, err
+	}
+	return nil, false, nil
+}
+
+func parseJSON(body []byte) ([]Field, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("error decoding body: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("error decoding body: unexpected data after the JSON value")
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	return objectFields(object), nil
+}
+
+// FileValue is the value of a file part, an object with the "filename",
+// "content_type" and "size" properties. Files are validated by the File type and
+// the max_size and media_types constraints.
+func FileValue(filename, contentType string, size int64) map[string]interface{} {
+	return map[string]interface{}{
+		"filename":     filename,
+		"content_type": contentType,
+		"size":         json.Number(strconv.FormatInt(size, 10)),
+	}
+}
+
+func parseMultipart(body []byte, boundary string) ([]Field, error) {
+	if boundary == "" {
+		return nil, fmt.Errorf("error decoding body: multipart content type has no boundary")
+	}
+
+	values := make(map[string][]interface{})
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding body: %w", err)
+		}
+
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+		if part.FileName() == "" {
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding body: %w", err)
+			}
+			values[name] = append(values[name], string(data))
+			continue
+		}
+
+		size, err := io.Copy(io.Discard, part)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding body: %w", err)
+		}
+		contentType := part.Header.Get("Content-Type")
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		values[name] = append(values[name], FileValue(part.FileName(), contentType, size))
+	}
+
+	fields := make([]Field, 0, len(values))
+	for name, value := range values {
+		field := Field{Name: name, Value: value}
+		if len(value) == 1 {
+			field.Value = value[0]
+		}
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields, nil
+}
+
+// parseXML converts the child elements of the root element into fields. An element
+// with attributes or child elements is an object, its attributes named with an "@"
+// prefix and its text, if any, named "#text". Other elements are their text.
+// Namespaces are dropped from the names.
+func parseXML(body []byte) ([]Field, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	var root interface{}
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding body: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if root != nil {
+				return nil, fmt.Errorf("error decoding body: unexpected element after the XML root")
+			}
+			root, err = xmlElement(decoder, t, 1)
+			if err != nil {
+				return nil, err
+			}
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) > 0 {
+				return nil, fmt.Errorf("error decoding body: unexpected text outside the XML root")
+			}
+		}
+	}
+
+	switch object := root.(type) {
+	case map[string]interface{}:
+		return objectFields(object), nil
+	case string:
+		if object != "" {
+			return nil, fmt.Errorf("error decoding body: XML root has text instead of elements")
+		}
+		return []Field{}, nil
+	}
+	return nil, fmt.Errorf("error decoding body: no XML root element")
+}
+
+func xmlElement(decoder *xml.Decoder, start xml.StartElement, depth int) (interface{}, error) {
+	if depth > maxXMLDepth {
+		return nil, fmt.Errorf("error decoding body: XML nested deeper than %d elements", maxXMLDepth)
+	}
+
+	children := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		children["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding body: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			value, err := xmlElement(decoder, t, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			// element values are never lists, so a list holds a repeated element
+			switch existing := children[t.Name.Local].(type) {
+			case nil:
+				children[t.Name.Local] = value
+			case []interface{}:
+				children[t.Name.Local] = append(existing, value)
+			default:
+				children[t.Name.Local] = []interface{}{existing, value}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(children) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				children["#text"] = content
+			}
+			return children, nil
+		}
+	}
+}
+
+// validateContentType checks the content type of the endpoint against the media
+// type the model expects. The content type is taken from the Content-Type header
+// when the endpoint has none, and its absence is an error when the model expects one.
+func validateContentType(endpoint *Endpoint, expected string) []ValidationError {
+	if expected == "" {
+		return nil
+	}
+
+	contentType := endpoint.ContentType
+	if contentType == "" {
+		contentType = headerValue(endpoint.Headers, "Content-Type")
+	}
+	if contentType == "" {
+		return []ValidationError{{
+			FieldName:  "Content-Type",
+			Path:       "/Content-Type",
+			ErrorType:  ErrMissingRequiredField,
+			Constraint: expected,
+		}}
+	}
+	if matchesMediaType(expected, contentType) {
+		return nil
+	}
+	return []ValidationError{{
+		FieldName:  "Content-Type",
+		Path:       "/Content-Type",
+		ErrorType:  ErrContentTypeMismatch,
+		ErrorValue: contentType,
+		Constraint: expected,
+	}}
+}
+
+// headerValue returns the first value of the named header, header names are case
+// insensitive
+func headerValue(headers []Field, name string) string {
+	for _, header := range headers {
+		if !strings.EqualFold(header.Name, name) {
+			continue
+		}
+		switch value := header.Value.(type) {
+		case string:
+			return value
+		case []interface{}:
+			if len(value) > 0 {
+				if text, ok := value[0].(string); ok {
+					return text
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// matchesMediaType reports whether the content type has the media type of the
+// pattern, ignoring parameters. The pattern may end with a wildcard subtype.
+func matchesMediaType(pattern, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	expected, _, err := mime.ParseMediaType(pattern)
+	if err != nil {
+		return false
+	}
+
+	if expected == "*/*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(expected, "/*"); ok {
+		return strings.HasPrefix(mediaType, prefix+"/")
+	}
+	return mediaType == expected
+}
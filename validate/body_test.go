@@ -0,0 +1,224 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multipartBody writes the values as form parts and the files as file parts, and
+// returns the body with its content type
+func multipartBody(t *testing.T, values [][2]string, files map[string]string) (string, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for _, value := range values {
+		require.NoError(t, writer.WriteField(value[0], value[1]))
+	}
+	for name, content := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="`+name+`"; filename="`+name+`.png"`)
+		header.Set("Content-Type", "image/png")
+		part, err := writer.CreatePart(header)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return body.String(), writer.FormDataContentType()
+}
+
+func TestParseBody(t *testing.T) {
+	multipartForm, multipartType := multipartBody(t, [][2]string{{"name", "bob"}, {"tag", "a"}, {"tag", "b"}}, map[string]string{"avatar": "12345"})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    []Field
+		wire        bool
+		err         bool
+	}{
+		{
+			name:        "Multipart",
+			contentType: multipartType,
+			body:        multipartForm,
+			expected: []Field{
+				{Name: "avatar", Value: FileValue("avatar.png", "image/png", 5)},
+				{Name: "name", Value: "bob"},
+				{Name: "tag", Value: []interface{}{"a", "b"}},
+			},
+			wire: true,
+		},
+		{name: "Multipart without boundary", contentType: "multipart/form-data", body: multipartForm, err: true},
+		{name: "Truncated multipart", contentType: multipartType, body: multipartForm[:len(multipartForm)/2], err: true},
+		{
+			name:        "XML",
+			contentType: "application/xml; charset=utf-8",
+			body: `<?xml version="1.0"?>
+				<user xmlns="urn:users" id="7">
+					<name>bob</name>
+					<age>42</age>
+					<tag>a</tag>
+					<tag>b</tag>
+					<address country="NL"><city>Amsterdam</city></address>
+					<note lang="en">hello</note>
+					<empty/>
+				</user>`,
+			expected: []Field{
+				{Name: "@id", Value: "7"},
+				{Name: "address", Value: map[string]interface{}{"@country": "NL", "city": "Amsterdam"}},
+				{Name: "age", Value: "42"},
+				{Name: "empty", Value: ""},
+				{Name: "name", Value: "bob"},
+				{Name: "note", Value: map[string]interface{}{"@lang": "en", "#text": "hello"}},
+				{Name: "tag", Value: []interface{}{"a", "b"}},
+			},
+			wire: true,
+		},
+		{name: "XML media type suffix", contentType: "application/soap+xml", body: `<envelope/>`, expected: []Field{}, wire: true},
+		{name: "XML root text", contentType: "text/xml", body: `<name>bob</name>`, err: true},
+		{name: "XML second root", contentType: "text/xml", body: `<a/><b/>`, err: true},
+		{name: "Malformed XML", contentType: "text/xml", body: `<user><name>bob</user>`, err: true},
+		{name: "XML too deep", contentType: "text/xml", body: strings.Repeat("<a>", maxXMLDepth+1) + strings.Repeat("</a>", maxXMLDepth+1), err: true},
+		{name: "Invalid content type", contentType: "application/", body: `{"name": "bob"}`},
+		{name: "No content type", body: `{"age": 42}`, expected: []Field{{Name: "age", Value: json.Number("42")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, wire, err := parseBody(tt.contentType, []byte(tt.body))
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fields)
+			assert.Equal(t, tt.wire, wire)
+		})
+	}
+}
+
+func TestMatchesMediaType(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		contentType string
+		expected    bool
+	}{
+		{"application/json", "application/json; charset=utf-8", true},
+		{"application/json", "Application/JSON", true},
+		{"application/json", "application/xml", false},
+		{"image/*", "image/png", true},
+		{"image/*", "imagery/png", false},
+		{"*/*", "text/plain", true},
+		{"application/json", "not a media type", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, matchesMediaType(tt.pattern, tt.contentType), "%s %s", tt.pattern, tt.contentType)
+	}
+}
+
+func TestValidateRequestContentTypes(t *testing.T) {
+	endpointModel := &model.EndpointModel{
+		Path:        "/users",
+		Method:      http.MethodPost,
+		ContentType: "multipart/form-data",
+		Body: map[string]model.FieldModel{
+			"age": {Types: []string{"Int"}, Required: true},
+			"avatar": {Types: []string{"File"}, Required: true, Constraints: &model.Constraints{
+				MaxSize:    int64Ptr(4),
+				MediaTypes: []string{"image/*"},
+			}},
+		},
+	}
+
+	body, contentType := multipartBody(t, [][2]string{{"age", "42"}}, map[string]string{"avatar": "1234"})
+	r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	report, err := ValidateRequest(r, endpointModel, nil)
+	require.NoError(t, err)
+	assert.True(t, report.Valid, "%+v", report)
+
+	body, contentType = multipartBody(t, [][2]string{{"age", "old"}}, map[string]string{"avatar": "12345"})
+	r = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	report, err = ValidateRequest(r, endpointModel, nil)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []ValidationError{
+		{FieldName: "age", Path: "/age", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: "old"},
+		{FieldName: "avatar", Path: "/avatar", ErrorType: ErrFileTooLarge, ErrorValue: FileValue("avatar.png", "image/png", 5), Constraint: int64(4)},
+	}, report.Body)
+
+	r = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"age": 42}`))
+	r.Header.Set("Content-Type", "application/json")
+	report, err = ValidateRequest(r, endpointModel, nil)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []ValidationError{{
+		FieldName:  "Content-Type",
+		Path:       "/Content-Type",
+		ErrorType:  ErrContentTypeMismatch,
+		ErrorValue: "application/json",
+		Constraint: "multipart/form-data",
+	}}, report.Headers)
+}
+
+func TestValidateReportContentType(t *testing.T) {
+	endpointModel := &model.EndpointModel{
+		Path:        "/orders",
+		Method:      http.MethodPost,
+		ContentType: "application/xml",
+		Body:        map[string]model.FieldModel{"id": {Types: []string{"Int"}}},
+	}
+
+	// a model content type makes the content type required
+	report, err := ValidateReport(&Endpoint{Path: "/orders", Method: http.MethodPost, Body: []Field{{Name: "id", Value: json.Number("1")}}}, endpointModel)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []ValidationError{{
+		FieldName:  "Content-Type",
+		Path:       "/Content-Type",
+		ErrorType:  ErrMissingRequiredField,
+		Constraint: "application/xml",
+	}}, report.Headers)
+
+	// the Content-Type header stands in for a missing content type
+	endpointModel.Headers = map[string]model.FieldModel{"Content-Type": {Types: []string{"String"}}}
+	report, err = ValidateReport(&Endpoint{Path: "/orders", Method: http.MethodPost, Headers: []Field{{Name: "Content-Type", Value: "text/plain"}}}, endpointModel)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []ValidationError{{
+		FieldName:  "Content-Type",
+		Path:       "/Content-Type",
+		ErrorType:  ErrContentTypeMismatch,
+		ErrorValue: "text/plain",
+		Constraint: "application/xml",
+	}}, report.Headers)
+
+	// the header name is matched case insensitively, only the header itself is not modelled
+	report, err = ValidateReport(&Endpoint{Path: "/orders", Method: http.MethodPost, Headers: []Field{{Name: "content-type", Value: "application/xml"}}}, endpointModel)
+	require.NoError(t, err)
+	require.Len(t, report.Headers, 1)
+	assert.Equal(t, ErrUnrecognizedField, report.Headers[0].ErrorType)
+	endpointModel.Headers = nil
+
+	report, err = ValidateReport(&Endpoint{Path: "/orders", Method: http.MethodPost, ContentType: "text/xml"}, endpointModel)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	require.Len(t, report.Headers, 1)
+	assert.Equal(t, ErrContentTypeMismatch, report.Headers[0].ErrorType)
+
+	r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`<order><id>7</id></order>`))
+	r.Header.Set("Content-Type", "application/xml")
+	report, err = ValidateRequest(r, endpointModel, nil)
+	require.NoError(t, err)
+	assert.True(t, report.Valid, "%+v", report)
+}
@@ -0,0 +1,52 @@
+package validate
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCache(t *testing.T) {
+	cache := newLRUCache[int](2)
+	cache.add("a", 1)
+	cache.add("b", 2)
+
+	// reading a keeps it, b is the least recently used
+	value, ok := cache.get("a")
+	require.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	cache.add("c", 3)
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, cache.len())
+
+	cache.add("a", 4)
+	value, _ = cache.get("a")
+	assert.Equal(t, 4, value)
+	assert.Equal(t, 2, cache.len())
+}
+
+func TestCompiledExpressionsBounded(t *testing.T) {
+	before := compiledExpressions.len()
+
+	// linted rules and failed compiles aren't cached
+	for i := 0; i < 10; i++ {
+		require.NoError(t, CompileRule(model.Rule{Expr: "body.a == " + strconv.Itoa(i)}))
+		_, err := compileExpression("body.a == (" + strconv.Itoa(i))
+		require.Error(t, err)
+	}
+	_, err := compileExpression(strings.Repeat(" ", maxExpressionLength+1))
+	require.Error(t, err)
+	assert.Equal(t, before, compiledExpressions.len())
+
+	for i := 0; i < maxCompiledExpressions+10; i++ {
+		_, err := compileExpression("body.b == " + strconv.Itoa(i))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, maxCompiledExpressions, compiledExpressions.len())
+}
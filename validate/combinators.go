@@ -0,0 +1,82 @@
+package validate
+
+import "github.com/evgeniron/API-Validator/model"
+
+const (
+	ErrAllOfMismatch  = "value does not match all of the allOf branches"
+	ErrAnyOfMismatch  = "value matches none of the anyOf branches"
+	ErrOneOfMismatch  = "value matches none of the oneOf branches"
+	ErrOneOfAmbiguous = "value matches more than one of the oneOf branches"
+	ErrNotMismatch    = "value matches the not branch"
+)
+
+// BranchError explains why the value failed a combinator branch, Index is the
+// position of the branch in the combinator
+type BranchError struct {
+	Index  int
+	Errors []ValidationError
+}
+
+// validateCombinators checks the value against the combinators of the field
+// model. Each violated combinator is reported once, together with the errors of
+// the branches the value failed.
+func (sv sectionValidator) validateCombinators(parent string, inputField Field, fieldModel model.FieldModel) []ValidationError {
+	var validationErrors []ValidationError
+	violation := func(errorType string, branches []BranchError, constraint interface{}) {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName:  inputField.Name,
+			Path:       fieldPointer(parent, inputField.Name),
+			ErrorType:  errorType,
+			ErrorValue: inputField.Value,
+			Constraint: constraint,
+			Branches:   branches,
+		})
+	}
+
+	if len(fieldModel.AllOf) > 0 {
+		failed, _ := sv.validateBranches(parent, inputField, fieldModel.AllOf)
+		if len(failed) > 0 {
+			violation(ErrAllOfMismatch, failed, nil)
+		}
+	}
+
+	if len(fieldModel.AnyOf) > 0 {
+		failed, matched := sv.validateBranches(parent, inputField, fieldModel.AnyOf)
+		if len(matched) == 0 {
+			violation(ErrAnyOfMismatch, failed, nil)
+		}
+	}
+
+	if len(fieldModel.OneOf) > 0 {
+		failed, matched := sv.validateBranches(parent, inputField, fieldModel.OneOf)
+		switch {
+		case len(matched) == 0:
+			violation(ErrOneOfMismatch, failed, nil)
+		case len(matched) > 1:
+			// the constraint lists the indexes of the matched branches
+			violation(ErrOneOfAmbiguous, nil, matched)
+		}
+	}
+
+	if fieldModel.Not != nil && len(sv.validateValue(parent, inputField, *fieldModel.Not)) == 0 {
+		violation(ErrNotMismatch, nil, nil)
+	}
+
+	return validationErrors
+}
+
+// validateBranches validates the value against every branch, returning the
+// errors of the failed branches and the indexes of the matched ones
+func (sv sectionValidator) validateBranches(parent string, inputField Field, branches []model.FieldModel) ([]BranchError, []int) {
+	var failed []BranchError
+	var matched []int
+	for i, branch := range branches {
+		branchErrors := sv.validateValue(parent, inputField, branch)
+		if len(branchErrors) == 0 {
+			matched = append(matched, i)
+			continue
+		}
+		failed = append(failed, BranchError{Index: i, Errors: branchErrors})
+	}
+	return failed, matched
+}
@@ -0,0 +1,135 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCombinators(t *testing.T) {
+	intOrString := model.FieldModel{AnyOf: []model.FieldModel{{Types: []string{"Int"}}, {Types: []string{"String"}}}}
+	contact := model.FieldModel{
+		Types: []string{"String"},
+		OneOf: []model.FieldModel{{Types: []string{"Email"}}, {Types: []string{"UUID"}}},
+	}
+	code := model.FieldModel{
+		AllOf: []model.FieldModel{
+			{Types: []string{"String"}},
+			{Constraints: &model.Constraints{MaxLength: intPtr(3)}},
+		},
+		Not: &model.FieldModel{Constraints: &model.Constraints{Enum: []interface{}{"ADM"}}},
+	}
+	intOrFloat := model.FieldModel{OneOf: []model.FieldModel{{Types: []string{"Int"}}, {Types: []string{"Float"}}}}
+
+	tests := []struct {
+		name       string
+		wire       bool
+		fieldModel model.FieldModel
+		value      interface{}
+		expected   []ValidationError
+	}{
+		{name: "anyOf first branch", fieldModel: intOrString, value: json.Number("42")},
+		{name: "anyOf second branch", fieldModel: intOrString, value: "42"},
+		{
+			name:       "anyOf no branch",
+			fieldModel: intOrString,
+			value:      true,
+			expected: []ValidationError{{
+				FieldName:  "field",
+				Path:       "/field",
+				ErrorType:  ErrAnyOfMismatch,
+				ErrorValue: true,
+				Branches: []BranchError{
+					{Index: 0, Errors: []ValidationError{{FieldName: "field", Path: "/field", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: true}}},
+					{Index: 1, Errors: []ValidationError{{FieldName: "field", Path: "/field", ErrorType: ErrMismatchType, ExpectedType: "String", ErrorValue: true}}},
+				},
+			}},
+		},
+		{name: "oneOf single branch", fieldModel: contact, value: "hello@world.test"},
+		{
+			name:       "oneOf no branch",
+			fieldModel: contact,
+			value:      "hello",
+			expected: []ValidationError{{
+				FieldName:  "field",
+				Path:       "/field",
+				ErrorType:  ErrOneOfMismatch,
+				ErrorValue: "hello",
+				Branches: []BranchError{
+					{Index: 0, Errors: []ValidationError{{FieldName: "field", Path: "/field", ErrorType: ErrMismatchType, ExpectedType: "Email", ErrorValue: "hello"}}},
+					{Index: 1, Errors: []ValidationError{{FieldName: "field", Path: "/field", ErrorType: ErrMismatchType, ExpectedType: "UUID", ErrorValue: "hello"}}},
+				},
+			}},
+		},
+		{
+			name:       "oneOf several branches",
+			fieldModel: intOrFloat,
+			value:      json.Number("7"),
+			expected: []ValidationError{
+				{FieldName: "field", Path: "/field", ErrorType: ErrOneOfAmbiguous, ErrorValue: json.Number("7"), Constraint: []int{0, 1}},
+			},
+		},
+		{name: "oneOf wire string", wire: true, fieldModel: intOrFloat, value: "7.5"},
+		{name: "allOf and not", fieldModel: code, value: "USR"},
+		{
+			name:       "allOf failed branch",
+			fieldModel: code,
+			value:      "USER",
+			expected: []ValidationError{{
+				FieldName:  "field",
+				Path:       "/field",
+				ErrorType:  ErrAllOfMismatch,
+				ErrorValue: "USER",
+				Branches: []BranchError{
+					{Index: 1, Errors: []ValidationError{{FieldName: "field", Path: "/field", ErrorType: ErrTooLong, ErrorValue: "USER", Constraint: 3}}},
+				},
+			}},
+		},
+		{
+			name:       "not matched",
+			fieldModel: code,
+			value:      "ADM",
+			expected:   []ValidationError{{FieldName: "field", Path: "/field", ErrorType: ErrNotMismatch, ErrorValue: "ADM"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := Field{Name: "field", Value: tt.value}
+			assert.Equal(t, tt.expected, sectionValidator{wireStrings: tt.wire}.validateValue("", field, tt.fieldModel))
+		})
+	}
+}
+
+func TestValidateNestedCombinators(t *testing.T) {
+	fieldModels := map[string]model.FieldModel{
+		"contact": {
+			Types: []string{"Object"},
+			OneOf: []model.FieldModel{
+				{Properties: map[string]model.FieldModel{"email": {Types: []string{"Email"}, Required: true}}},
+				{Properties: map[string]model.FieldModel{"phone": {Types: []string{"String"}, Required: true}}},
+			},
+		},
+	}
+
+	validationErrors := sectionValidator{}.validateFields("", []Field{
+		{Name: "contact", Value: map[string]interface{}{"email": "hello"}},
+	}, fieldModels, nil)
+
+	assert.Equal(t, []ValidationError{{
+		FieldName:  "contact",
+		Path:       "/contact",
+		ErrorType:  ErrOneOfMismatch,
+		ErrorValue: map[string]interface{}{"email": "hello"},
+		Branches: []BranchError{
+			{Index: 0, Errors: []ValidationError{
+				{FieldName: "email", Path: "/contact/email", ErrorType: ErrMismatchType, ExpectedType: "Email", ErrorValue: "hello"},
+			}},
+			{Index: 1, Errors: []ValidationError{
+				{FieldName: "email", Path: "/contact/email", ErrorType: ErrUnrecognizedField},
+				{FieldName: "phone", Path: "/contact/phone", ErrorType: ErrMissingRequiredField},
+			}},
+		},
+	}}, validationErrors)
+}
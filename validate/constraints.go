@@ -0,0 +1,312 @@
+package validate
+
+import (
+	"encoding/json"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/evgeniron/API-Validator/model"
+)
+
+const (
+	ErrBelowMinimum          = "value below minimum"
+	ErrAboveMaximum          = "value above maximum"
+	ErrBelowExclusiveMinimum = "value not above exclusive minimum"
+	ErrAboveExclusiveMaximum = "value not below exclusive maximum"
+	ErrNotMultipleOf         = "value not a multiple of"
+	ErrNumberOutOfRange      = "number out of comparable range"
+	ErrTooShort              = "value shorter than min length"
+	ErrTooLong               = "value longer than max length"
+	ErrPatternMismatch       = "value does not match pattern"
+	ErrNotInEnum             = "value not in enum"
+	ErrConstMismatch         = "value does not equal const"
+	ErrTooFewItems           = "list has fewer than min items"
+	ErrTooManyItems          = "list has more than max items"
+	ErrDuplicateItems        = "list items are not unique"
+	ErrFileTooLarge          = "file larger than max size"
+	ErrMediaTypeNotAllowed   = "file media type not allowed"
+)
+
+// maxCompiledPatterns bounds the compiled patterns cached
+const maxCompiledPatterns = 1024
+
+// patterns caches the compiled constraint patterns by their expression
+var patterns = newLRUCache[*regexp.Regexp](maxCompiledPatterns)
+
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := patterns.get(expr); ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	patterns.add(expr, re)
+	return re, nil
+}
+
+// constraintChecker collects the constraint violations of a single field
+type constraintChecker struct {
+	field  Field
+	path   string
+	errors []ValidationError
+}
+
+func (cc *constraintChecker) violation(errorType string, constraint interface{}) {
+	cc.errors = append(cc.errors, ValidationError{
+		FieldName:  cc.field.Name,
+		Path:       cc.path,
+		ErrorType:  errorType,
+		ErrorValue: cc.field.Value,
+		Constraint: constraint,
+	})
+}
+
+// validateConstraints checks value, the field value as compared by the constraints,
+// against the constraints of the field model
+func validateConstraints(parent string, inputField Field, value interface{}, constraints *model.Constraints) []ValidationError {
+	cc := &constraintChecker{field: inputField, path: fieldPointer(parent, inputField.Name)}
+
+	switch v := value.(type) {
+	case string:
+		cc.checkString(v, constraints)
+	case []interface{}:
+		cc.checkList(v, constraints)
+	case map[string]interface{}:
+		if f, ok := fileValue(v); ok {
+			cc.checkFile(f, constraints)
+		}
+	case bool, nil:
+	default:
+		cc.checkNumber(v, constraints)
+	}
+
+	if len(constraints.Enum) > 0 && !inEnum(value, constraints.Enum) {
+		cc.violation(ErrNotInEnum, constraints.Enum)
+	}
+
+	if constraints.Const != nil && !equalValues(value, constraints.Const) {
+		cc.violation(ErrConstMismatch, constraints.Const)
+	}
+
+	return cc.errors
+}
+
+func (cc *constraintChecker) checkNumber(value interface{}, constraints *model.Constraints) {
+	if _, ok := numberLiteral(value); !ok {
+		return
+	}
+
+	number, ok := ratValue(value)
+	if !ok {
+		if constraints.Minimum != "" || constraints.Maximum != "" || constraints.ExclusiveMinimum != "" ||
+			constraints.ExclusiveMaximum != "" || constraints.MultipleOf != "" {
+			cc.violation(ErrNumberOutOfRange, nil)
+		}
+		return
+	}
+
+	bounds := []struct {
+		bound     json.Number
+		errorType string
+		violated  func(cmp int) bool
+	}{
+		{constraints.Minimum, ErrBelowMinimum, func(cmp int) bool { return cmp < 0 }},
+		{constraints.Maximum, ErrAboveMaximum, func(cmp int) bool { return cmp > 0 }},
+		{constraints.ExclusiveMinimum, ErrBelowExclusiveMinimum, func(cmp int) bool { return cmp <= 0 }},
+		{constraints.ExclusiveMaximum, ErrAboveExclusiveMaximum, func(cmp int) bool { return cmp >= 0 }},
+	}
+	for _, b := range bounds {
+		if b.bound == "" {
+			continue
+		}
+		bound, ok := ratValue(b.bound)
+		if !ok {
+			// log error here
+			continue
+		}
+		if b.violated(number.Cmp(bound)) {
+			cc.violation(b.errorType, b.bound)
+		}
+	}
+
+	if constraints.MultipleOf != "" {
+		multiple, ok := ratValue(constraints.MultipleOf)
+		if !ok || multiple.Sign() <= 0 {
+			// log error here
+			return
+		}
+		if !new(big.Rat).Quo(number, multiple).IsInt() {
+			cc.violation(ErrNotMultipleOf, constraints.MultipleOf)
+		}
+	}
+}
+
+func (cc *constraintChecker) checkString(value string, constraints *model.Constraints) {
+	length := utf8.RuneCountInString(value)
+	if constraints.MinLength != nil && length < *constraints.MinLength {
+		cc.violation(ErrTooShort, *constraints.MinLength)
+	}
+	if constraints.MaxLength != nil && length > *constraints.MaxLength {
+		cc.violation(ErrTooLong, *constraints.MaxLength)
+	}
+
+	if constraints.Pattern != "" {
+		re, err := compilePattern(constraints.Pattern)
+		if err != nil {
+			// log error here
+			return
+		}
+		if !re.MatchString(value) {
+			cc.violation(ErrPatternMismatch, constraints.Pattern)
+		}
+	}
+}
+
+func (cc *constraintChecker) checkFile(f file, constraints *model.Constraints) {
+	if constraints.MaxSize != nil && f.size > *constraints.MaxSize {
+		cc.violation(ErrFileTooLarge, *constraints.MaxSize)
+	}
+
+	if len(constraints.MediaTypes) > 0 {
+		for _, mediaType := range constraints.MediaTypes {
+			if matchesMediaType(mediaType, f.contentType) {
+				return
+			}
+		}
+		cc.violation(ErrMediaTypeNotAllowed, constraints.MediaTypes)
+	}
+}
+
+func (cc *constraintChecker) checkList(items []interface{}, constraints *model.Constraints) {
+	if constraints.MinItems != nil && len(items) < *constraints.MinItems {
+		cc.violation(ErrTooFewItems, *constraints.MinItems)
+	}
+	if constraints.MaxItems != nil && len(items) > *constraints.MaxItems {
+		cc.violation(ErrTooManyItems, *constraints.MaxItems)
+	}
+
+	if constraints.UniqueItems {
+		seen := make(map[string]struct{}, len(items))
+		var key strings.Builder
+		for _, item := range items {
+			key.Reset()
+			if !writeCanonical(&key, item) {
+				continue
+			}
+			if _, duplicate := seen[key.String()]; duplicate {
+				cc.violation(ErrDuplicateItems, true)
+				return
+			}
+			seen[key.String()] = struct{}{}
+		}
+	}
+}
+
+// writeCanonical writes an encoding of the value under which values are equal
+// exactly when equalValues holds, so lists are checked for duplicates in linear
+// time. It reports false for values equalValues never holds for.
+func writeCanonical(b *strings.Builder, value interface{}) bool {
+	if literal, ok := numberLiteral(value); ok {
+		if number, ok := ratValue(value); ok {
+			b.WriteString("n" + number.RatString() + ";")
+		} else {
+			b.WriteString("N" + strings.ToLower(literal) + ";")
+		}
+		return true
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		b.WriteString("[")
+		for _, item := range v {
+			if !writeCanonical(b, item) {
+				return false
+			}
+		}
+		b.WriteString("]")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString("{")
+		for _, key := range keys {
+			b.WriteString(strconv.Quote(key))
+			if !writeCanonical(b, v[key]) {
+				return false
+			}
+		}
+		b.WriteString("}")
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case bool:
+		b.WriteString(strconv.FormatBool(v) + ";")
+	case nil:
+		b.WriteString("null;")
+	default:
+		return false
+	}
+	return true
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if equalValues(value, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues compares decoded JSON values. Numbers are equal when their values
+// are, whether they were decoded as json.Number or float64.
+func equalValues(a, b interface{}) bool {
+	if aLiteral, ok := numberLiteral(a); ok {
+		bLiteral, ok := numberLiteral(b)
+		if !ok {
+			return false
+		}
+		aNumber, aOk := ratValue(a)
+		bNumber, bOk := ratValue(b)
+		if aOk && bOk {
+			return aNumber.Cmp(bNumber) == 0
+		}
+		return strings.EqualFold(aLiteral, bLiteral)
+	}
+
+	switch av := a.(type) {
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equalValues(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, exists := bv[key]
+			if !exists || !equalValues(value, other) {
+				return false
+			}
+		}
+		return true
+	case string, bool, nil:
+		return a == b
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package validate
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func TestValidateConstraints(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       interface{}
+		constraints model.Constraints
+		expected    []string
+	}{
+		{
+			name:        "Number within bounds",
+			value:       json.Number("42"),
+			constraints: model.Constraints{Minimum: "0", Maximum: "150"},
+		},
+		{
+			name:        "Number on inclusive bounds",
+			value:       json.Number("150.0"),
+			constraints: model.Constraints{Minimum: "150", Maximum: "150"},
+		},
+		{
+			name:        "Number out of bounds",
+			value:       json.Number("-1"),
+			constraints: model.Constraints{Minimum: "0", Maximum: "-5"},
+			expected:    []string{ErrBelowMinimum, ErrAboveMaximum},
+		},
+		{
+			name:        "Number on exclusive bounds",
+			value:       json.Number("10"),
+			constraints: model.Constraints{ExclusiveMinimum: "10", ExclusiveMaximum: "10"},
+			expected:    []string{ErrBelowExclusiveMinimum, ErrAboveExclusiveMaximum},
+		},
+		{
+			name:        "Decimal multiple of",
+			value:       json.Number("0.3"),
+			constraints: model.Constraints{MultipleOf: "0.1"},
+		},
+		{
+			name:        "Not a multiple of",
+			value:       float64(7),
+			constraints: model.Constraints{MultipleOf: "2"},
+			expected:    []string{ErrNotMultipleOf},
+		},
+		{
+			name:        "Number beyond comparable range",
+			value:       json.Number("1e1000"),
+			constraints: model.Constraints{Maximum: "10"},
+			expected:    []string{ErrNumberOutOfRange},
+		},
+		{
+			name:        "Numeric constraints ignore strings",
+			value:       "1000",
+			constraints: model.Constraints{Maximum: "10"},
+		},
+		{
+			name:        "String length in runes",
+			value:       "שלום",
+			constraints: model.Constraints{MinLength: intPtr(4), MaxLength: intPtr(4)},
+		},
+		{
+			name:        "String too short and too long",
+			value:       "ab",
+			constraints: model.Constraints{MinLength: intPtr(3), MaxLength: intPtr(1)},
+			expected:    []string{ErrTooShort, ErrTooLong},
+		},
+		{
+			name:        "Pattern match",
+			value:       "user_42",
+			constraints: model.Constraints{Pattern: "^[a-z0-9_]{3,20}$"},
+		},
+		{
+			name:        "Pattern mismatch",
+			value:       "User 42",
+			constraints: model.Constraints{Pattern: "^[a-z0-9_]{3,20}$"},
+			expected:    []string{ErrPatternMismatch},
+		},
+		{
+			name:        "Enum",
+			value:       "DE",
+			constraints: model.Constraints{Enum: []interface{}{"US", "DE", "IL"}},
+		},
+		{
+			name:        "Not in enum",
+			value:       "FR",
+			constraints: model.Constraints{Enum: []interface{}{"US", "DE", "IL"}},
+			expected:    []string{ErrNotInEnum},
+		},
+		{
+			name:        "Numeric enum compares values",
+			value:       json.Number("2.0"),
+			constraints: model.Constraints{Enum: []interface{}{float64(1), json.Number("2")}},
+		},
+		{
+			name:        "Const",
+			value:       map[string]interface{}{"a": json.Number("1")},
+			constraints: model.Constraints{Const: map[string]interface{}{"a": float64(1)}},
+		},
+		{
+			name:        "Const mismatch",
+			value:       true,
+			constraints: model.Constraints{Const: "true"},
+			expected:    []string{ErrConstMismatch},
+		},
+		{
+			name:        "List items",
+			value:       []interface{}{"a", "b"},
+			constraints: model.Constraints{MinItems: intPtr(1), MaxItems: intPtr(2), UniqueItems: true},
+		},
+		{
+			name:        "List items out of bounds and duplicated",
+			value:       []interface{}{json.Number("1"), "1", float64(1)},
+			constraints: model.Constraints{MinItems: intPtr(4), MaxItems: intPtr(2), UniqueItems: true},
+			expected:    []string{ErrTooFewItems, ErrTooManyItems, ErrDuplicateItems},
+		},
+		{
+			name: "Unique items compare values",
+			value: []interface{}{
+				map[string]interface{}{"a": json.Number("1"), "b": []interface{}{"x"}},
+				map[string]interface{}{"b": []interface{}{"x"}, "a": json.Number("1.0")},
+			},
+			constraints: model.Constraints{UniqueItems: true},
+			expected:    []string{ErrDuplicateItems},
+		},
+		{
+			name:        "Unique items keep types apart",
+			value:       []interface{}{"1", json.Number("1"), true, "true", nil, "null", []interface{}{"a"}, "a", json.Number("1e400")},
+			constraints: model.Constraints{UniqueItems: true},
+		},
+		{
+			name:        "File within size and media types",
+			value:       FileValue("avatar.png", "image/png", 1024),
+			constraints: model.Constraints{MaxSize: int64Ptr(1024), MediaTypes: []string{"application/pdf", "image/*"}},
+		},
+		{
+			name:        "File too large and of another media type",
+			value:       FileValue("notes.txt", "text/plain; charset=utf-8", 1025),
+			constraints: model.Constraints{MaxSize: int64Ptr(1024), MediaTypes: []string{"image/*"}},
+			expected:    []string{ErrFileTooLarge, ErrMediaTypeNotAllowed},
+		},
+		{
+			name:        "File constraints ignore objects",
+			value:       map[string]interface{}{"size": json.Number("2048")},
+			constraints: model.Constraints{MaxSize: int64Ptr(1024)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := Field{Name: "field", Value: tt.value}
+			var actual []string
+			for _, validationError := range validateConstraints("", field, tt.value, &tt.constraints) {
+				assert.Equal(t, "/field", validationError.Path)
+				assert.Equal(t, tt.value, validationError.ErrorValue)
+				actual = append(actual, validationError.ErrorType)
+			}
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestUniqueItemsLargeList(t *testing.T) {
+	items := make([]interface{}, 100000)
+	for i := range items {
+		items[i] = json.Number(strconv.Itoa(i))
+	}
+	field := Field{Name: "ids", Value: items}
+	assert.Empty(t, validateConstraints("", field, items, &model.Constraints{UniqueItems: true}))
+
+	items = append(items, json.Number("99999.0"))
+	assert.Len(t, validateConstraints("", field, items, &model.Constraints{UniqueItems: true}), 1)
+}
+
+func TestValidateReportConstraints(t *testing.T) {
+	endpointModel := &model.EndpointModel{
+		Path:   "/users",
+		Method: "GET",
+		QueryParams: map[string]model.FieldModel{
+			"age": {Types: []string{"Int"}, Constraints: &model.Constraints{Minimum: "0", Maximum: "150"}},
+		},
+		Body: map[string]model.FieldModel{
+			"tags": {
+				Types:       []string{"List"},
+				Items:       &model.FieldModel{Types: []string{"String"}, Constraints: &model.Constraints{MaxLength: intPtr(3)}},
+				Constraints: &model.Constraints{UniqueItems: true},
+			},
+		},
+	}
+
+	report, err := ValidateReport(&Endpoint{
+		Path:        "/users",
+		Method:      "GET",
+		QueryParams: []Field{{Name: "age", Value: "151"}},
+		Body:        []Field{{Name: "tags", Value: []interface{}{"go", "rust"}}},
+	}, endpointModel)
+	assert.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []ValidationError{
+		{FieldName: "age", Path: "/age", ErrorType: ErrAboveMaximum, ErrorValue: "151", Constraint: json.Number("150")},
+	}, report.QueryParams)
+	assert.Equal(t, []ValidationError{
+		{FieldName: "1", Path: "/tags/1", ErrorType: ErrTooLong, ErrorValue: "rust", Constraint: 3},
+	}, report.Body)
+}
@@ -0,0 +1,747 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+Rule expressions are a small language without side effects, evaluated against the
+endpoint sections "path", "query", "headers" and "body":
+
+	body.end_date > body.start_date
+	headers["X-Tenant"] == body.tenant_id
+	body.contact_method != "sms" || present(body.phone)
+	date(body.end_date) > date(body.start_date)
+
+Fields are read with "." or with an index in brackets, missing fields are absent and
+only equal to null. Expressions support the literals true, false, null, numbers and
+quoted strings, the operators || && ! == != < <= > >= + - * / and the functions
+present, len, lower, number and date. Numeric strings compare as numbers against
+numbers, as query params and headers are strings on the wire.
+There are no loops or assignments, and expressions are bounded in length and
+nesting, so evaluating a rule is always cheap.
+*/
+
+const (
+	maxExpressionLength = 1024
+	maxExpressionDepth  = 32
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators are ordered so that two character operators match first
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", ".", ","}
+
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(input); {
+		r, size := utf8.DecodeRuneInString(input[pos:])
+		switch {
+		case unicode.IsSpace(r):
+			pos += size
+		case r == '_' || unicode.IsLetter(r):
+			start := pos
+			for pos < len(input) {
+				r, size := utf8.DecodeRuneInString(input[pos:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				pos += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: input[start:pos], pos: start})
+		case r >= '0' && r <= '9':
+			start := pos
+			for pos < len(input) && strings.ContainsRune("0123456789.eE", rune(input[pos])) {
+				// the sign of an exponent belongs to the number
+				if (input[pos] == 'e' || input[pos] == 'E') && pos+1 < len(input) && (input[pos+1] == '+' || input[pos+1] == '-') {
+					pos++
+				}
+				pos++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: input[start:pos], pos: start})
+		case r == '"' || r == '\'':
+			text, end, err := scanString(input, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: pos})
+			pos = end
+		default:
+			operator := ""
+			for _, op := range operators {
+				if strings.HasPrefix(input[pos:], op) {
+					operator = op
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q at %d", r, pos)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: pos})
+			pos += len(operator)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(input)}), nil
+}
+
+// scanString reads the quoted string starting at pos and returns it unquoted
+// together with the position after the closing quote
+func scanString(input string, pos int) (string, int, error) {
+	quote := input[pos]
+	var text strings.Builder
+	for i := pos + 1; i < len(input); i++ {
+		switch c := input[i]; {
+		case c == quote:
+			return text.String(), i + 1, nil
+		case c == '\\' && i+1 < len(input):
+			i++
+			switch input[i] {
+			case 'n':
+				text.WriteByte('\n')
+			case 't':
+				text.WriteByte('\t')
+			default:
+				text.WriteByte(input[i])
+			}
+		default:
+			text.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at %d", pos)
+}
+
+// expression is a compiled rule expression
+type expression interface {
+	eval(env ruleEnv) (interface{}, error)
+}
+
+// ruleEnv holds the endpoint sections by name, each section maps field names to values
+type ruleEnv map[string]map[string]interface{}
+
+var ruleSections = map[string]bool{"path": true, "query": true, "headers": true, "body": true}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// maxCompiledExpressions bounds the compiled expressions cached
+const maxCompiledExpressions = 1024
+
+// compiledExpressions caches the expressions of the evaluated rules by their source
+var compiledExpressions = newLRUCache[expression](maxCompiledExpressions)
+
+// compileExpression parses the expression source, expressions that compile are
+// cached so the rules of a model are compiled once
+func compileExpression(source string) (expression, error) {
+	if err := checkExpressionLength(source); err != nil {
+		return nil, err
+	}
+	if expr, ok := compiledExpressions.get(source); ok {
+		return expr, nil
+	}
+
+	expr, err := parseExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	compiledExpressions.add(source, expr)
+	return expr, nil
+}
+
+func checkExpressionLength(source string) error {
+	if len(source) > maxExpressionLength {
+		return fmt.Errorf("expression longer than %d characters", maxExpressionLength)
+	}
+	return nil
+}
+
+// parseExpression compiles the expression source without caching it
+func parseExpression(source string) (expression, error) {
+	if err := checkExpressionLength(source); err != nil {
+		return nil, err
+	}
+
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", next.text, next.pos)
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(operators ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range operators {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		return fmt.Errorf("expected %q at %d", op, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (expression, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExpressionDepth {
+		return nil, fmt.Errorf("expression nested deeper than %d", maxExpressionDepth)
+	}
+
+	return p.parseBinary(0)
+}
+
+// precedence lists the binary operators from the lowest precedence to the highest
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+func (p *parser) parseBinary(level int) (expression, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (expression, error) {
+	if op, ok := p.accept("!", "-"); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxExpressionDepth {
+			return nil, fmt.Errorf("expression nested deeper than %d", maxExpressionDepth)
+		}
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (expression, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.accept("."); ok {
+			name := p.next()
+			if name.kind != tokenIdent {
+				return nil, fmt.Errorf("expected field name at %d", name.pos)
+			}
+			expr = &indexExpr{target: expr, index: &literalExpr{value: name.text}}
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			expr = &indexExpr{target: expr, index: index}
+			continue
+		}
+		return expr, nil
+	}
+}
+
+func (p *parser) parsePrimary() (expression, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		number, ok := ratValue(json.Number(t.text))
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q at %d", t.text, t.pos)
+		}
+		return &literalExpr{value: number}, nil
+	case tokenString:
+		return &literalExpr{value: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalExpr{value: true}, nil
+		case "false":
+			return &literalExpr{value: false}, nil
+		case "null":
+			return &literalExpr{value: nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		if !ruleSections[t.text] {
+			return nil, fmt.Errorf("unknown name %q at %d, expecting path, query, headers or body", t.text, t.pos)
+		}
+		return &sectionExpr{name: t.text}, nil
+	case tokenOperator:
+		if t.text == "(" {
+			expr, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return expr, p.expect(")")
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+func (p *parser) parseCall(name token) (expression, error) {
+	fn, ok := ruleFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at %d", name.text, name.pos)
+	}
+
+	var args []expression
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("wrong number of arguments for %s at %d", name.text, name.pos)
+	}
+	return &callExpr{name: name.text, fn: fn.call, args: args}, nil
+}
+
+// absent is the value of missing fields
+type absentValue struct{}
+
+var absent = absentValue{}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e *literalExpr) eval(ruleEnv) (interface{}, error) {
+	return e.value, nil
+}
+
+type sectionExpr struct {
+	name string
+}
+
+func (e *sectionExpr) eval(env ruleEnv) (interface{}, error) {
+	return env[e.name], nil
+}
+
+type indexExpr struct {
+	target expression
+	index  expression
+}
+
+func (e *indexExpr) eval(env ruleEnv) (interface{}, error) {
+	target, err := e.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	index, err := e.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{} = absent
+	var exists bool
+	switch t := target.(type) {
+	case map[string]interface{}:
+		if key, ok := index.(string); ok {
+			value, exists = t[key]
+		}
+	case []interface{}:
+		if i, ok := index.(*big.Rat); ok && i.IsInt() && i.Num().IsInt64() {
+			if n := i.Num().Int64(); n >= 0 && n < int64(len(t)) {
+				value, exists = t[n], true
+			}
+		}
+	}
+	if !exists {
+		return absent, nil
+	}
+	return ruleValue(value)
+}
+
+// ruleValue converts decoded JSON numbers into exact numbers
+func ruleValue(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case string, bool, nil, map[string]interface{}, []interface{}:
+		return value, nil
+	}
+
+	if _, ok := numberLiteral(value); ok {
+		number, ok := ratValue(value)
+		if !ok {
+			return nil, fmt.Errorf("number %v out of range", value)
+		}
+		return number, nil
+	}
+	return value, nil
+}
+
+type unaryExpr struct {
+	op      string
+	operand expression
+}
+
+func (e *unaryExpr) eval(env ruleEnv) (interface{}, error) {
+	value, err := e.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.op == "!" {
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("! expects a boolean, got %s", describeValue(value))
+		}
+		return !b, nil
+	}
+
+	number, ok := asNumber(value)
+	if !ok {
+		return nil, fmt.Errorf("- expects a number, got %s", describeValue(value))
+	}
+	return new(big.Rat).Neg(number), nil
+}
+
+type binaryExpr struct {
+	op    string
+	left  expression
+	right expression
+}
+
+func (e *binaryExpr) eval(env ruleEnv) (interface{}, error) {
+	left, err := e.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.op == "&&" || e.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s expects booleans, got %s", e.op, describeValue(left))
+		}
+		// short circuit
+		if (e.op == "&&" && !l) || (e.op == "||" && l) {
+			return l, nil
+		}
+		right, err := e.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s expects booleans, got %s", e.op, describeValue(right))
+		}
+		return r, nil
+	}
+
+	right, err := e.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "==":
+		return ruleEqual(left, right), nil
+	case "!=":
+		return !ruleEqual(left, right), nil
+	case "<", "<=", ">", ">=":
+		cmp, err := ruleCompare(left, right)
+		if err != nil {
+			return nil, fmt.Errorf("%s %w", e.op, err)
+		}
+		switch e.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+		return cmp >= 0, nil
+	}
+	return arithmetic(e.op, left, right)
+}
+
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	if op == "+" {
+		l, lok := left.(string)
+		r, rok := right.(string)
+		if lok && rok {
+			return l + r, nil
+		}
+	}
+
+	l, lok := asNumber(left)
+	r, rok := asNumber(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s expects numbers, got %s and %s", op, describeValue(left), describeValue(right))
+	}
+
+	switch op {
+	case "+":
+		return new(big.Rat).Add(l, r), nil
+	case "-":
+		return new(big.Rat).Sub(l, r), nil
+	case "*":
+		return new(big.Rat).Mul(l, r), nil
+	}
+	if r.Sign() == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+	return new(big.Rat).Quo(l, r), nil
+}
+
+// asNumber returns numbers and numeric strings as exact numbers
+func asNumber(value interface{}) (*big.Rat, bool) {
+	switch v := value.(type) {
+	case *big.Rat:
+		return v, true
+	case string:
+		literal := wireNumber(v)
+		if !numberPattern.MatchString(literal.String()) {
+			return nil, false
+		}
+		return ratValue(literal)
+	}
+	return nil, false
+}
+
+func ruleEqual(left, right interface{}) bool {
+	if left == absent {
+		left = nil
+	}
+	if right == absent {
+		right = nil
+	}
+
+	_, leftNumber := left.(*big.Rat)
+	_, rightNumber := right.(*big.Rat)
+	if leftNumber || rightNumber {
+		l, lok := asNumber(left)
+		r, rok := asNumber(right)
+		return lok && rok && l.Cmp(r) == 0
+	}
+
+	if l, ok := left.(time.Time); ok {
+		r, ok := right.(time.Time)
+		return ok && l.Equal(r)
+	}
+	return equalValues(left, right)
+}
+
+func ruleCompare(left, right interface{}) (int, error) {
+	_, leftNumber := left.(*big.Rat)
+	_, rightNumber := right.(*big.Rat)
+	if leftNumber || rightNumber {
+		l, lok := asNumber(left)
+		r, rok := asNumber(right)
+		if lok && rok {
+			return l.Cmp(r), nil
+		}
+	}
+
+	switch l := left.(type) {
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	case time.Time:
+		if r, ok := right.(time.Time); ok {
+			return l.Compare(r), nil
+		}
+	}
+	return 0, fmt.Errorf("can't compare %s and %s", describeValue(left), describeValue(right))
+}
+
+func describeValue(value interface{}) string {
+	switch value.(type) {
+	case absentValue:
+		return "a missing field"
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case *big.Rat:
+		return "a number"
+	case string:
+		return "a string"
+	case time.Time:
+		return "a date"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+type ruleFunction struct {
+	minArgs int
+	maxArgs int
+	call    func(args []interface{}) (interface{}, error)
+}
+
+// dateLayouts are tried in order by date() without an explicit layout
+var dateLayouts = []string{"02-01-2006", "2006-01-02", time.RFC3339}
+
+var ruleFunctions = map[string]ruleFunction{
+	// present reports whether the field exists
+	"present": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return args[0] != absent, nil
+	}},
+	"len": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case string:
+			return big.NewRat(int64(utf8.RuneCountInString(v)), 1), nil
+		case []interface{}:
+			return big.NewRat(int64(len(v)), 1), nil
+		case map[string]interface{}:
+			return big.NewRat(int64(len(v)), 1), nil
+		}
+		return nil, fmt.Errorf("len expects a string, list or object, got %s", describeValue(args[0]))
+	}},
+	"lower": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("lower expects a string, got %s", describeValue(args[0]))
+		}
+		return strings.ToLower(s), nil
+	}},
+	"number": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		number, ok := asNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("number expects a number or a numeric string, got %s", describeValue(args[0]))
+		}
+		return number, nil
+	}},
+	// date parses a date string with the layout, or with the Date type layout,
+	// ISO 8601 dates and RFC 3339 timestamps when no layout is given
+	"date": {minArgs: 1, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("date expects a string, got %s", describeValue(args[0]))
+		}
+
+		layouts := dateLayouts
+		if len(args) == 2 {
+			layout, ok := args[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("date expects a string layout, got %s", describeValue(args[1]))
+			}
+			layouts = []string{layout}
+		}
+
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid date %q", s)
+	}},
+}
+
+type callExpr struct {
+	name string
+	fn   func(args []interface{}) (interface{}, error)
+	args []expression
+}
+
+func (e *callExpr) eval(env ruleEnv) (interface{}, error) {
+	args := make([]interface{}, 0, len(e.args))
+	for _, arg := range e.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+	return e.fn(args)
+}
@@ -0,0 +1,110 @@
+package validate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	env := ruleEnv{
+		"path":  {"id": "7"},
+		"query": {"limit": "25", "sort": "name"},
+		"headers": {
+			"X-Tenant": "acme",
+		},
+		"body": {
+			"tenant_id":      "acme",
+			"start_date":     "01-02-2023",
+			"end_date":       "15-02-2023",
+			"contact_method": "sms",
+			"price":          json.Number("2.5"),
+			"quantity":       json.Number("4"),
+			"total":          json.Number("10"),
+			"note":           nil,
+			"tags":           []interface{}{"a", "b"},
+			"address":        map[string]interface{}{"city": "Haifa"},
+		},
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{expr: `true`, expected: true},
+		{expr: `!false && (false || true)`, expected: true},
+		{expr: `headers["X-Tenant"] == body.tenant_id`, expected: true},
+		{expr: `date(body.end_date) > date(body.start_date)`, expected: true},
+		{expr: `date(body.end_date) <= date(body.start_date)`, expected: false},
+		{expr: `date("2023-02-01", "2006-01-02") == date(body.start_date)`, expected: true},
+		{expr: `body.contact_method != "sms" || present(body.phone)`, expected: false},
+		{expr: `present(body.note) && body.note == null`, expected: true},
+		{expr: `body.phone == null`, expected: true},
+		{expr: `body.price * body.quantity == body.total`, expected: true},
+		{expr: `body.total / 4 - 0.5 == 2`, expected: true},
+		{expr: `-body.total < 0`, expected: true},
+		{expr: `query.limit <= 100 && number(path.id) == 7`, expected: true},
+		{expr: `query.sort == 'name'`, expected: true},
+		{expr: `query.sort < "title"`, expected: true},
+		{expr: `len(body.tags) == 2 && body.tags[1] == "b" && !present(body.tags[2])`, expected: true},
+		{expr: `lower(body.address.city) == "haifa" && !present(body.address.zip.code)`, expected: true},
+		{expr: `body.tags == body.tags && body.address != body.tags`, expected: true},
+		{expr: `body.price == 2.50 && body.price != "2.5x"`, expected: true},
+		{expr: `"a" + "b" == "ab"`, expected: true},
+		// short circuit skips the invalid comparison
+		{expr: `false && body.tags > 1`, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			holds, err := evaluateCondition(tt.expr, env)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, holds)
+		})
+	}
+
+	evalErrors := []string{
+		`body.tags > 1`,
+		`body.phone > 1`,
+		`body.total / 0 == 1`,
+		`!body.total`,
+		`body.total && true`,
+		`date(body.tenant_id) == date(body.start_date)`,
+		`len(body.total) == 1`,
+		`body.total`,
+	}
+	for _, expr := range evalErrors {
+		_, err := evaluateCondition(expr, env)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCompileExpression(t *testing.T) {
+	invalid := []string{
+		``,
+		`body.`,
+		`body.a ==`,
+		`(true`,
+		`true)`,
+		`request.a == 1`,
+		`exec("ls")`,
+		`present()`,
+		`present(body.a, body.b)`,
+		`body.a = 1`,
+		`"unterminated`,
+		`body.a == 1e999999`,
+		`body.a $ 1`,
+		strings.Repeat("(", 100) + "true" + strings.Repeat(")", 100),
+		strings.Repeat("!", 100) + "true",
+		"body.a == \"" + strings.Repeat("x", maxExpressionLength) + "\"",
+	}
+	for _, expr := range invalid {
+		_, err := compileExpression(expr)
+		assert.Error(t, err, expr)
+	}
+
+	_, err := compileExpression(`date(body.a, "2006-01-02") >= date("01-01-2023") && (query.x == 1e3 || !present(headers["X-A"]))`)
+	assert.NoError(t, err)
+}
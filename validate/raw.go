@@ -0,0 +1,156 @@
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ReadRawRequest reads an HTTP/1.1 request as sent on the wire. Requests without a
+// Content-Length or chunked encoding take the rest of the input as their body, so
+// hand written requests need not count their body.
+func ReadRawRequest(raw io.Reader) (*http.Request, error) {
+	reader := bufio.NewReader(raw)
+	r, err := http.ReadRequest(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading raw request: %w", err)
+	}
+
+	if r.ContentLength == 0 && len(r.TransferEncoding) == 0 {
+		body, err := io.ReadAll(io.LimitReader(reader, MaxBodySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("error reading raw request: %w", err)
+		}
+		if len(body) > 0 {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		}
+	}
+	return r, nil
+}
+
+// HAREntry is an entry of an HTTP Archive (HAR 1.2)
+type HAREntry struct {
+	Request  HARRequest   `json:"request"`
+	Response *HARResponse `json:"response,omitempty"`
+}
+
+type HARRequest struct {
+	Method   string         `json:"method"`
+	URL      string         `json:"url"`
+	Headers  []HARNameValue `json:"headers"`
+	PostData *HARPostData   `json:"postData,omitempty"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is the request body, either as text or, for forms, as params
+type HARPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []HARNameValue `json:"params,omitempty"`
+}
+
+// HARResponse is the response of an entry, Status is 0 for requests that got no
+// response, e.g. blocked ones
+type HARResponse struct {
+	Status  int            `json:"status"`
+	Headers []HARNameValue `json:"headers"`
+	Content HARContent     `json:"content"`
+}
+
+// HARContent is the response body, Encoding is "base64" for binary bodies
+type HARContent struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// DecodeHAREntry decodes a single HAR entry
+func DecodeHAREntry(r io.Reader) (*HAREntry, error) {
+	var entry HAREntry
+	if err := json.NewDecoder(r).Decode(&entry); err != nil {
+		return nil, fmt.Errorf("error decoding HAR entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// HTTPRequest rebuilds the request of the entry. The query is taken from the URL,
+// and HTTP/2 pseudo headers, e.g. ":authority", are dropped.
+func (e *HAREntry) HTTPRequest() (*http.Request, error) {
+	if e.Request.Method == "" {
+		return nil, fmt.Errorf("HAR entry has no request method")
+	}
+
+	var body io.Reader
+	if postData := e.Request.PostData; postData != nil {
+		text := postData.Text
+		if text == "" && len(postData.Params) > 0 {
+			values := url.Values{}
+			for _, param := range postData.Params {
+				values.Add(param.Name, param.Value)
+			}
+			text = values.Encode()
+		}
+		body = strings.NewReader(text)
+	}
+
+	r, err := http.NewRequest(e.Request.Method, e.Request.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HAR request: %w", err)
+	}
+	for _, header := range e.Request.Headers {
+		if strings.HasPrefix(header.Name, ":") {
+			continue
+		}
+		r.Header.Add(header.Name, header.Value)
+	}
+	if postData := e.Request.PostData; postData != nil && postData.MimeType != "" && r.Header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", postData.MimeType)
+	}
+	return r, nil
+}
+
+// HTTPResponse rebuilds the response of the entry to the request, nil when the
+// entry has no response
+func (e *HAREntry) HTTPResponse(request *http.Request) (*http.Response, error) {
+	if e.Response == nil || e.Response.Status == 0 {
+		return nil, nil
+	}
+
+	body := []byte(e.Response.Content.Text)
+	if e.Response.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(e.Response.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HAR response content: %w", err)
+		}
+		body = decoded
+	}
+
+	resp := &http.Response{
+		StatusCode:    e.Response.Status,
+		Header:        make(http.Header, len(e.Response.Headers)),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+	for _, header := range e.Response.Headers {
+		if strings.HasPrefix(header.Name, ":") {
+			continue
+		}
+		resp.Header.Add(header.Name, header.Value)
+	}
+	if e.Response.Content.MimeType != "" && resp.Header.Get("Content-Type") == "" {
+		resp.Header.Set("Content-Type", e.Response.Content.MimeType)
+	}
+	return resp, nil
+}
@@ -0,0 +1,128 @@
+package validate
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadRawRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		method string
+		path   string
+		body   string
+		err    bool
+	}{
+		{
+			name:   "Content length",
+			raw:    "POST /users?notify=true HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/json\r\nContent-Length: 13\r\n\r\n{\"age\": 42}\r\n",
+			method: http.MethodPost,
+			path:   "/users",
+			body:   "{\"age\": 42}\r\n",
+		},
+		{
+			name:   "Uncounted body",
+			raw:    "PUT /users/1 HTTP/1.1\nHost: example.com\nContent-Type: application/json\n\n{\"age\": 42}",
+			method: http.MethodPut,
+			path:   "/users/1",
+			body:   `{"age": 42}`,
+		},
+		{
+			name:   "Chunked body",
+			raw:    "POST /users HTTP/1.1\r\nHost: example.com\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n",
+			method: http.MethodPost,
+			path:   "/users",
+			body:   "hello",
+		},
+		{
+			name:   "Absolute URL",
+			raw:    "GET http://example.com/users HTTP/1.1\r\n\r\n",
+			method: http.MethodGet,
+			path:   "/users",
+		},
+		{name: "Malformed", raw: "not a request", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ReadRawRequest(strings.NewReader(tt.raw))
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.method, r.Method)
+			assert.Equal(t, tt.path, r.URL.Path)
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
+
+func TestHAREntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    string
+		expected *Endpoint
+		err      bool
+	}{
+		{
+			name: "JSON body",
+			entry: `{"request": {"method": "POST", "url": "https://example.com/users?notify=true",
+				"headers": [{"name": ":authority", "value": "example.com"}, {"name": "content-type", "value": "application/json"}],
+				"postData": {"mimeType": "application/json", "text": "{\"age\": 42}"}}}`,
+			expected: &Endpoint{
+				Path:        "/users",
+				Method:      http.MethodPost,
+				QueryParams: []Field{{Name: "notify", Value: "true"}},
+				Headers:     []Field{{Name: "Content-Type", Value: "application/json"}},
+				Body:        []Field{{Name: "age", Value: json.Number("42")}},
+				ContentType: "application/json",
+			},
+		},
+		{
+			name: "Form params",
+			entry: `{"request": {"method": "POST", "url": "https://example.com/login", "headers": [],
+				"postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "user", "value": "bob"}]}}}`,
+			expected: &Endpoint{
+				Path:        "/login",
+				Method:      http.MethodPost,
+				QueryParams: []Field{},
+				Headers:     []Field{{Name: "Content-Type", Value: "application/x-www-form-urlencoded"}},
+				Body:        []Field{{Name: "user", Value: "bob"}},
+				ContentType: "application/x-www-form-urlencoded",
+				wireBody:    true,
+			},
+		},
+		{name: "No method", entry: `{"request": {"url": "https://example.com/users"}}`, err: true},
+		{name: "Invalid URL", entry: `{"request": {"method": "GET", "url": "://"}}`, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := DecodeHAREntry(strings.NewReader(tt.entry))
+			require.NoError(t, err)
+
+			r, err := entry.HTTPRequest()
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			endpoint, err := ParseRequest(r)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, endpoint)
+		})
+	}
+
+	_, err := DecodeHAREntry(strings.NewReader(`[]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/evgeniron/API-Validator/model"
+)
+
+// MaxBodySize bounds the request and response bodies read to be validated
+const MaxBodySize = 1 << 20
+
+// ErrBodyTooLarge is returned for bodies larger than MaxBodySize
+var ErrBodyTooLarge = errors.New("body too large to validate")
+
+// ParseRequest converts a live request into an endpoint. Query params and headers
+// with a single value are strings and repeated ones lists of strings, the body is
+// parsed by its content type, see parseBody. The body is left readable for the
+// next handler, even when parsing fails.
+func ParseRequest(r *http.Request) (*Endpoint, error) {
+	endpoint := &Endpoint{
+		Path:        r.URL.Path,
+		Method:      r.Method,
+		QueryParams: valuesFields(r.URL.Query()),
+		Headers:     valuesFields(r.Header),
+		ContentType: r.Header.Get("Content-Type"),
+	}
+
+	body, err := readBody(&r.Body)
+	if err != nil {
+		return endpoint, err
+	}
+	endpoint.Body, endpoint.wireBody, err = parseBody(r.Header.Get("Content-Type"), body)
+	return endpoint, err
+}
+
+// readBody reads a request or response body and replaces it with an unread copy
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(*body, MaxBodySize+1))
+	if err != nil {
+		*body = io.NopCloser(bytes.NewReader(data))
+		return nil, fmt.Errorf("error reading body: %w", err)
+	}
+	if len(data) > MaxBodySize {
+		*body = readCloser{Reader: io.MultiReader(bytes.NewReader(data), *body), Closer: *body}
+		return nil, ErrBodyTooLarge
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func valuesFields(values map[string][]string) []Field {
+	fields := make([]Field, 0, len(values))
+	for name, value := range values {
+		field := Field{Name: name}
+		if len(value) == 1 {
+			field.Value = value[0]
+		} else {
+			list := make([]interface{}, 0, len(value))
+			for _, v := range value {
+				list = append(list, v)
+			}
+			field.Value = list
+		}
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// ModelHeaders keeps the headers the model declares, named as in the model. Header
+// names are case insensitive, and clients and proxies add headers of their own
+// which are not part of the API.
+func ModelHeaders(headers []Field, fieldModels map[string]model.FieldModel) []Field {
+	names := make(map[string]string, len(fieldModels))
+	for name := range fieldModels {
+		names[http.CanonicalHeaderKey(name)] = name
+	}
+
+	var modelled []Field
+	for _, header := range headers {
+		if name, ok := names[http.CanonicalHeaderKey(header.Name)]; ok {
+			modelled = append(modelled, Field{Name: name, Value: header.Value})
+		}
+	}
+	return modelled
+}
+
+// ValidateRequest validates a live request against its model, resolving custom
+// types from db. Only the headers the model declares are validated.
+func ValidateRequest(r *http.Request, endpointModel *model.EndpointModel, db model.Reader) (*ValidationReport, error) {
+	endpoint, err := ParseRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Headers = ModelHeaders(endpoint.Headers, endpointModel.Headers)
+	return ValidateReportWithTypes(endpoint, endpointModel, db)
+}
@@ -0,0 +1,127 @@
+package validate
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    []Field
+		err         bool
+	}{
+		{
+			name:     "JSON body",
+			body:     `{"name": "bob", "age": 42, "tags": ["a"]}`,
+			expected: []Field{{Name: "age", Value: json.Number("42")}, {Name: "name", Value: "bob"}, {Name: "tags", Value: []interface{}{"a"}}},
+		},
+		{
+			name:        "JSON media type",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			body:        `{"name": "bob"}`,
+			expected:    []Field{{Name: "name", Value: "bob"}},
+		},
+		{
+			name:        "Form body",
+			contentType: "application/x-www-form-urlencoded",
+			body:        `name=bob&tag=a&tag=b`,
+			expected:    []Field{{Name: "name", Value: "bob"}, {Name: "tag", Value: []interface{}{"a", "b"}}},
+		},
+		{name: "Malformed form", contentType: "application/x-www-form-urlencoded", body: `name=%zz`, err: true},
+		{name: "Empty body"},
+		{name: "Other content type", contentType: "text/plain", body: `hello`},
+		{name: "Not an object", body: `[1, 2]`},
+		{name: "Trailing data", body: `{"name": "bob"} {}`, err: true},
+		{name: "Malformed", body: `{"name": `, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/users?limit=10&tag=a&tag=b", strings.NewReader(tt.body))
+			r.Header.Set("Authorization", "Bearer abc")
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			endpoint, err := ParseRequest(r)
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, endpoint.Body)
+			}
+
+			assert.Equal(t, "/users", endpoint.Path)
+			assert.Equal(t, http.MethodPost, endpoint.Method)
+			assert.Equal(t, []Field{{Name: "limit", Value: "10"}, {Name: "tag", Value: []interface{}{"a", "b"}}}, endpoint.QueryParams)
+			assert.Contains(t, endpoint.Headers, Field{Name: "Authorization", Value: "Bearer abc"})
+
+			// the body is still readable
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
+
+func TestParseRequestBodyTooLarge(t *testing.T) {
+	large := `{"data": "` + strings.Repeat("x", MaxBodySize) + `"}`
+	r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(large))
+
+	_, err := ParseRequest(r)
+	require.ErrorIs(t, err, ErrBodyTooLarge)
+
+	body, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	assert.Equal(t, large, string(body))
+}
+
+func TestValidateRequest(t *testing.T) {
+	endpointModel := &model.EndpointModel{
+		Path:    "/users/{id}",
+		Method:  http.MethodPut,
+		Headers: map[string]model.FieldModel{"x-tenant": {Types: []string{"String"}, Required: true}},
+		Body:    map[string]model.FieldModel{"age": {Types: []string{"Int"}, Required: true}},
+		PathParams: map[string]model.FieldModel{
+			"id": {Types: []string{"Int"}, Required: true},
+		},
+		QueryParams: map[string]model.FieldModel{
+			"notify": {Types: []string{"Boolean"}, Constraints: &model.Constraints{Const: true}},
+		},
+	}
+
+	// query params are strings on the wire, booleans included
+	r := httptest.NewRequest(http.MethodPut, "/users/7?notify=true", strings.NewReader(`{"age": 42}`))
+	r.Header.Set("X-Tenant", "acme")
+	r.Header.Set("User-Agent", "test")
+	report, err := ValidateRequest(r, endpointModel, nil)
+	require.NoError(t, err)
+	assert.True(t, report.Valid, "%+v", report)
+
+	// form values are strings on the wire
+	r = httptest.NewRequest(http.MethodPut, "/users/7", strings.NewReader(`age=42`))
+	r.Header.Set("X-Tenant", "acme")
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	report, err = ValidateRequest(r, endpointModel, nil)
+	require.NoError(t, err)
+	assert.True(t, report.Valid, "%+v", report)
+
+	r = httptest.NewRequest(http.MethodPut, "/users/abc?notify=yes", strings.NewReader(`{"age": "old"}`))
+	report, err = ValidateRequest(r, endpointModel, nil)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []ValidationError{{FieldName: "x-tenant", Path: "/x-tenant", ErrorType: ErrMissingRequiredField}}, report.Headers)
+	assert.Len(t, report.PathParams, 1)
+	assert.Len(t, report.Body, 1)
+	assert.Len(t, report.QueryParams, 2)
+}
@@ -0,0 +1,124 @@
+package validate
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evgeniron/API-Validator/model"
+)
+
+const (
+	ErrMissingDependentField   = "missing dependent required field"
+	ErrMissingConditionalField = "missing conditionally required field"
+	ErrOneOfRequired           = "exactly one field of the group required"
+	ErrMutuallyExclusive       = "mutually exclusive fields present"
+)
+
+// validateRequirements checks the conditional requirements against the existing
+// fields and their values. Fields already reported as missing are skipped, a
+// field is reported missing once. Group errors are reported at the parent with
+// the group as the field name. wire is set for sections whose values are strings
+// on the wire, see matchesValue.
+func validateRequirements(parent string, requirements *model.Requirements, existingFields map[string]interface{}, missing map[string]bool, wire bool) []ValidationError {
+	var validationErrors []ValidationError
+	requireField := func(fieldName, errorType string, constraint interface{}) {
+		if _, exists := existingFields[fieldName]; exists || missing[fieldName] {
+			return
+		}
+		missing[fieldName] = true
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName:  fieldName,
+			Path:       fieldPointer(parent, fieldName),
+			ErrorType:  errorType,
+			Constraint: constraint,
+		})
+	}
+
+	triggers := make([]string, 0, len(requirements.DependentRequired))
+	for trigger := range requirements.DependentRequired {
+		triggers = append(triggers, trigger)
+	}
+	sort.Strings(triggers)
+	for _, trigger := range triggers {
+		if _, exists := existingFields[trigger]; !exists {
+			continue
+		}
+		for _, fieldName := range requirements.DependentRequired[trigger] {
+			requireField(fieldName, ErrMissingDependentField, trigger)
+		}
+	}
+
+	for _, requiredIf := range requirements.RequiredIf {
+		value, exists := existingFields[requiredIf.Field]
+		if !exists || !matchesValue(value, requiredIf.Values, wire) {
+			continue
+		}
+		for _, fieldName := range requiredIf.Required {
+			requireField(fieldName, ErrMissingConditionalField, requiredIf)
+		}
+	}
+
+	groupError := func(group []string, errorType string, present []string) {
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName:  strings.Join(group, ","),
+			Path:       parent,
+			ErrorType:  errorType,
+			ErrorValue: present,
+			Constraint: group,
+		})
+	}
+	for _, group := range requirements.OneOfRequired {
+		if present := presentFields(group, existingFields); len(present) != 1 {
+			groupError(group, ErrOneOfRequired, present)
+		}
+	}
+	for _, group := range requirements.MutuallyExclusive {
+		if present := presentFields(group, existingFields); len(present) > 1 {
+			groupError(group, ErrMutuallyExclusive, present)
+		}
+	}
+	return validationErrors
+}
+
+func presentFields(group []string, existingFields map[string]interface{}) []string {
+	present := []string{}
+	for _, fieldName := range group {
+		if _, exists := existingFields[fieldName]; exists {
+			present = append(present, fieldName)
+		}
+	}
+	return present
+}
+
+// matchesValue reports whether the value equals one of the candidates. Values of
+// wire sections, e.g. query params and headers, are strings on the wire, so their
+// strings also match the numbers and booleans they spell.
+func matchesValue(value interface{}, candidates []interface{}, wire bool) bool {
+	for _, candidate := range candidates {
+		if equalValues(value, candidate) {
+			return true
+		}
+
+		text, ok := value.(string)
+		if !ok || !wire {
+			continue
+		}
+		switch c := candidate.(type) {
+		case bool:
+			if text == strconv.FormatBool(c) {
+				return true
+			}
+		default:
+			if _, numeric := numberLiteral(candidate); !numeric {
+				continue
+			}
+			number, ok := asNumber(text)
+			expected, valid := ratValue(candidate)
+			if ok && valid && number.Cmp(expected) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
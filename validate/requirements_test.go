@@ -0,0 +1,155 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRequirements(t *testing.T) {
+	requiredIf := model.RequiredIf{Field: "contact_method", Values: []interface{}{"sms", "call"}, Required: []string{"phone"}}
+	requirements := &model.Requirements{
+		DependentRequired: map[string][]string{"card_number": {"card_expiry", "card_cvv"}},
+		RequiredIf:        []model.RequiredIf{requiredIf},
+		OneOfRequired:     [][]string{{"email", "phone"}},
+		MutuallyExclusive: [][]string{{"card_number", "iban"}},
+	}
+	fieldModels := map[string]model.FieldModel{
+		"card_expiry": {Types: []string{"String"}, Required: true},
+	}
+
+	tests := []struct {
+		name           string
+		existingFields map[string]interface{}
+		expected       []ValidationError
+	}{
+		{
+			name:           "Requirements met",
+			existingFields: map[string]interface{}{"email": "a@b.c", "contact_method": "email", "card_number": "4111", "card_expiry": "01/30", "card_cvv": "123"},
+		},
+		{
+			name:           "Dependent fields missing",
+			existingFields: map[string]interface{}{"email": "a@b.c", "card_number": "4111"},
+			expected: []ValidationError{
+				{FieldName: "card_expiry", Path: "/card_expiry", ErrorType: ErrMissingRequiredField},
+				{FieldName: "card_cvv", Path: "/card_cvv", ErrorType: ErrMissingDependentField, Constraint: "card_number"},
+			},
+		},
+		{
+			name:           "Conditional field missing",
+			existingFields: map[string]interface{}{"email": "a@b.c", "contact_method": "sms", "card_expiry": "01/30"},
+			expected: []ValidationError{
+				{FieldName: "phone", Path: "/phone", ErrorType: ErrMissingConditionalField, Constraint: requiredIf},
+			},
+		},
+		{
+			name:           "None of the group",
+			existingFields: map[string]interface{}{"card_expiry": "01/30"},
+			expected: []ValidationError{
+				{FieldName: "email,phone", Path: "", ErrorType: ErrOneOfRequired, ErrorValue: []string{}, Constraint: []string{"email", "phone"}},
+			},
+		},
+		{
+			name:           "More than one of the group",
+			existingFields: map[string]interface{}{"email": "a@b.c", "phone": "555", "card_number": "4111", "iban": "DE89", "card_expiry": "01/30", "card_cvv": "123"},
+			expected: []ValidationError{
+				{FieldName: "email,phone", Path: "", ErrorType: ErrOneOfRequired, ErrorValue: []string{"email", "phone"}, Constraint: []string{"email", "phone"}},
+				{FieldName: "card_number,iban", Path: "", ErrorType: ErrMutuallyExclusive, ErrorValue: []string{"card_number", "iban"}, Constraint: []string{"card_number", "iban"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, validateRequiredFields("", fieldModels, requirements, tt.existingFields, false))
+		})
+	}
+}
+
+func TestMatchesValue(t *testing.T) {
+	tests := []struct {
+		value      interface{}
+		candidates []interface{}
+		wire       bool
+		expected   bool
+	}{
+		{value: "sms", candidates: []interface{}{"sms"}, expected: true},
+		{value: "SMS", candidates: []interface{}{"sms"}, expected: false},
+		{value: json.Number("1.0"), candidates: []interface{}{json.Number("1")}, expected: true},
+		{value: "1.0", candidates: []interface{}{json.Number("1")}, wire: true, expected: true},
+		{value: "1.0", candidates: []interface{}{json.Number("1")}, expected: false},
+		{value: "true", candidates: []interface{}{true}, wire: true, expected: true},
+		{value: "true", candidates: []interface{}{true}, expected: false},
+		{value: true, candidates: []interface{}{"true"}, wire: true, expected: false},
+		{value: "one", candidates: []interface{}{json.Number("1")}, wire: true, expected: false},
+		{value: nil, candidates: []interface{}{nil}, expected: true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, matchesValue(tt.value, tt.candidates, tt.wire), "%v in %v (wire %v)", tt.value, tt.candidates, tt.wire)
+	}
+}
+
+func TestValidateReportRequirements(t *testing.T) {
+	endpointModel := &model.EndpointModel{
+		Path:        "/contacts",
+		Method:      "POST",
+		QueryParams: map[string]model.FieldModel{"notify": {Types: []string{"String"}}, "channel": {Types: []string{"String"}}},
+		Body: map[string]model.FieldModel{
+			"address": {Types: []string{"Object"}, Properties: map[string]model.FieldModel{
+				"street": {Types: []string{"String"}},
+				"city":   {Types: []string{"String"}},
+			}, Requirements: &model.Requirements{DependentRequired: map[string][]string{"street": {"city"}}}},
+		},
+		Requirements: map[string]model.Requirements{
+			"query_params": {RequiredIf: []model.RequiredIf{{Field: "notify", Values: []interface{}{true}, Required: []string{"channel"}}}},
+		},
+	}
+
+	report, err := ValidateReport(&Endpoint{
+		Path:        "/contacts",
+		Method:      "POST",
+		QueryParams: []Field{{Name: "notify", Value: "true"}},
+		Body:        []Field{{Name: "address", Value: map[string]interface{}{"street": "Herzl 1"}}},
+	}, endpointModel)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []ValidationError{{
+		FieldName:  "channel",
+		Path:       "/channel",
+		ErrorType:  ErrMissingConditionalField,
+		Constraint: endpointModel.Requirements["query_params"].RequiredIf[0],
+	}}, report.QueryParams)
+	assert.Equal(t, []ValidationError{
+		{FieldName: "city", Path: "/address/city", ErrorType: ErrMissingDependentField, Constraint: "street"},
+	}, report.Body)
+}
+
+func TestValidateReportRequirementsJSONBody(t *testing.T) {
+	endpointModel := &model.EndpointModel{
+		Path:   "/orders",
+		Method: "POST",
+		Body: map[string]model.FieldModel{
+			"priority": {},
+			"reason":   {Types: []string{"String"}},
+		},
+		Requirements: map[string]model.Requirements{
+			"body": {RequiredIf: []model.RequiredIf{{Field: "priority", Values: []interface{}{json.Number("1")}, Required: []string{"reason"}}}},
+		},
+	}
+
+	// JSON body values are typed, the string "1" doesn't match the number 1
+	report, err := ValidateReport(&Endpoint{Path: "/orders", Method: "POST", Body: []Field{{Name: "priority", Value: "1"}}}, endpointModel)
+	require.NoError(t, err)
+	assert.Empty(t, report.Body)
+
+	report, err = ValidateReport(&Endpoint{Path: "/orders", Method: "POST", Body: []Field{{Name: "priority", Value: json.Number("1")}}}, endpointModel)
+	require.NoError(t, err)
+	assert.Equal(t, []ValidationError{{
+		FieldName:  "reason",
+		Path:       "/reason",
+		ErrorType:  ErrMissingConditionalField,
+		Constraint: endpointModel.Requirements["body"].RequiredIf[0],
+	}}, report.Body)
+}
@@ -0,0 +1,117 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/utils"
+)
+
+const ErrUnexpectedStatus = "unexpected status code"
+
+// Response is a response of the endpoint with the path and method
+type Response struct {
+	Path    string  `json:"path"`
+	Method  string  `json:"method"`
+	Status  int     `json:"status"`
+	Headers []Field `json:"headers"`
+	Body    []Field `json:"body"`
+	// wireBody is set for bodies whose values are strings on the wire, e.g. forms
+	wireBody bool
+}
+
+// ResponseReport is the validation report of a response. StatusErrors holds the
+// error of a status code the endpoint model has no response for.
+type ResponseReport struct {
+	Path         string
+	Method       string
+	Status       int
+	StatusErrors []ValidationError
+	Headers      []ValidationError
+	Body         []ValidationError
+	Valid        bool
+}
+
+func (rr *ResponseReport) IsValid() *ResponseReport {
+	if rr == nil {
+		return nil
+	}
+
+	if len(rr.StatusErrors) == 0 && len(rr.Headers) == 0 && len(rr.Body) == 0 {
+		rr.Valid = true
+	}
+	return rr
+}
+
+func ParseResponse(r *http.Request) (*Response, error) {
+	var response Response
+
+	err := utils.Decode(r, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ParseHTTPResponse converts a live response into a response, like ParseRequest
+// converts requests. The response must carry its request, the body is left readable.
+func ParseHTTPResponse(resp *http.Response) (*Response, error) {
+	if resp.Request == nil {
+		return nil, fmt.Errorf("response has no request")
+	}
+
+	response := &Response{
+		Path:    resp.Request.URL.Path,
+		Method:  resp.Request.Method,
+		Status:  resp.StatusCode,
+		Headers: valuesFields(resp.Header),
+	}
+
+	body, err := readBody(&resp.Body)
+	if err != nil {
+		return response, err
+	}
+	response.Body, response.wireBody, err = parseBody(resp.Header.Get("Content-Type"), body)
+	return response, err
+}
+
+// ValidateResponse validates the response against the response model of its status
+// code, resolving custom types from db. Only the headers the response model
+// declares are validated, as servers add headers of their own.
+func ValidateResponse(response *Response, endpointModel *model.EndpointModel, db model.Reader) (*ResponseReport, error) {
+	report := &ResponseReport{Path: response.Path, Method: response.Method, Status: response.Status}
+	responseModel, ok := endpointModel.Responses[response.Status]
+	if !ok {
+		report.StatusErrors = []ValidationError{{
+			FieldName:  "status",
+			Path:       "/status",
+			ErrorType:  ErrUnexpectedStatus,
+			ErrorValue: response.Status,
+			Constraint: modelledStatuses(endpointModel.Responses),
+		}}
+		return report.IsValid(), nil
+	}
+
+	types := typeResolver{db: db}
+	wireValidator := sectionValidator{wireStrings: true, types: types}
+	jsonValidator := sectionValidator{types: types}
+	report.Headers = wireValidator.validateFields("", ModelHeaders(response.Headers, responseModel.Headers), responseModel.Headers, nil)
+	bodyValidator := jsonValidator
+	if response.wireBody {
+		bodyValidator = wireValidator
+	}
+	report.Body = bodyValidator.validateFields("", response.Body, responseModel.Body, nil)
+	return report.IsValid(), nil
+}
+
+func modelledStatuses(responses map[int]model.ResponseModel) []int {
+	statuses := make([]int, 0, len(responses))
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	return statuses
+}
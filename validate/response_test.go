@@ -0,0 +1,107 @@
+package validate
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateResponse(t *testing.T) {
+	endpointModel := model.NewModel().
+		WithPath("/users/{id}").
+		WithMethod("GET").
+		WithResponses(map[int]model.Response{
+			200: {
+				Headers: []model.Field{{Name: "X-Request-Id", Types: []string{"String"}, Required: true}},
+				Body: []model.Field{
+					{Name: "id", Types: []string{"Int"}, Required: true},
+					{Name: "email", Types: []string{"Email"}},
+				},
+			},
+			404: {Body: []model.Field{{Name: "error", Types: []string{"String"}, Required: true}}},
+		})
+
+	tests := []struct {
+		name     string
+		response Response
+		expected *ResponseReport
+	}{
+		{
+			name: "Valid",
+			response: Response{
+				Status:  200,
+				Headers: []Field{{Name: "x-request-id", Value: "abc"}, {Name: "Date", Value: "Mon, 02 Jan 2006"}},
+				Body:    []Field{{Name: "id", Value: json.Number("1")}, {Name: "email", Value: "bob@example.com"}},
+			},
+			expected: &ResponseReport{Status: 200, Valid: true},
+		},
+		{
+			name: "Missing and mistyped fields",
+			response: Response{
+				Status: 200,
+				Body:   []Field{{Name: "id", Value: "1"}, {Name: "name", Value: "bob"}},
+			},
+			expected: &ResponseReport{
+				Status:  200,
+				Headers: []ValidationError{{FieldName: "X-Request-Id", Path: "/X-Request-Id", ErrorType: ErrMissingRequiredField}},
+				Body: []ValidationError{
+					{FieldName: "id", Path: "/id", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: "1"},
+					{FieldName: "name", Path: "/name", ErrorType: ErrUnrecognizedField},
+				},
+			},
+		},
+		{
+			name:     "Other status",
+			response: Response{Status: 404, Body: []Field{{Name: "error", Value: "not found"}}},
+			expected: &ResponseReport{Status: 404, Valid: true},
+		},
+		{
+			name:     "Unexpected status",
+			response: Response{Status: 500},
+			expected: &ResponseReport{
+				Status:       500,
+				StatusErrors: []ValidationError{{FieldName: "status", Path: "/status", ErrorType: ErrUnexpectedStatus, ErrorValue: 500, Constraint: []int{200, 404}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ValidateResponse(&tt.response, endpointModel, nil)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, report)
+		})
+	}
+}
+
+func TestParseHTTPResponse(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}, "X-Request-Id": {"abc"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id": 1}`)),
+		Request:    request,
+	}
+
+	response, err := ParseHTTPResponse(resp)
+	require.NoError(t, err)
+	require.Equal(t, &Response{
+		Path:    "/users/1",
+		Method:  http.MethodGet,
+		Status:  http.StatusOK,
+		Headers: []Field{{Name: "Content-Type", Value: "application/json"}, {Name: "X-Request-Id", Value: "abc"}},
+		Body:    []Field{{Name: "id", Value: json.Number("1")}},
+	}, response)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, `{"id": 1}`, string(body))
+
+	_, err = ParseHTTPResponse(&http.Response{StatusCode: http.StatusOK})
+	require.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package validate
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/evgeniron/API-Validator/model"
+)
+
+const (
+	ErrRuleFailed  = "rule failed"
+	ErrRuleInvalid = "rule could not be evaluated"
+)
+
+// CompileRule reports whether the expressions of the rule compile. The rules are
+// checked before their models are accepted, so they aren't cached.
+func CompileRule(rule model.Rule) error {
+	if rule.Expr == "" {
+		return fmt.Errorf("missing expr")
+	}
+	if _, err := parseExpression(rule.Expr); err != nil {
+		return fmt.Errorf("invalid expr: %w", err)
+	}
+	if rule.When != "" {
+		if _, err := parseExpression(rule.When); err != nil {
+			return fmt.Errorf("invalid when: %w", err)
+		}
+	}
+	return nil
+}
+
+// LintRules returns the problems of the model rules, in the form of model.Lint
+func LintRules(models []model.Endpoint) []model.Problem {
+	var problems []model.Problem
+	for i, endpoint := range models {
+		for j, rule := range endpoint.Rules {
+			if err := CompileRule(rule); err != nil {
+				problems = append(problems, model.Problem{
+					Index:    i,
+					Location: fmt.Sprintf("%s %s rules/%s", endpoint.Method, endpoint.Path, ruleName(j, rule)),
+					Message:  err.Error(),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+// ruleName names the rule in reports, unnamed rules are named by their index
+func ruleName(index int, rule model.Rule) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return strconv.Itoa(index)
+}
+
+// validateRules evaluates the model rules against the endpoint, a rule fails when
+// it doesn't hold and is invalid when it can't be evaluated, e.g. when comparing a
+// number to a string
+func validateRules(endpoint *Endpoint, path []Field, rules []model.Rule) []ValidationError {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	env := ruleEnv{
+		"path":    sectionValues(path),
+		"query":   sectionValues(endpoint.QueryParams),
+		"headers": sectionValues(endpoint.Headers),
+		"body":    sectionValues(endpoint.Body),
+	}
+
+	var validationErrors []ValidationError
+	for i, rule := range rules {
+		name := ruleName(i, rule)
+		holds, err := evaluateRule(rule, env)
+		if err != nil {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName:  name,
+				Path:       fieldPointer("", name),
+				ErrorType:  ErrRuleInvalid,
+				ErrorValue: err.Error(),
+				Constraint: rule.Expr,
+			})
+			continue
+		}
+		if !holds {
+			message := rule.Message
+			if message == "" {
+				message = rule.Expr
+			}
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName:  name,
+				Path:       fieldPointer("", name),
+				ErrorType:  ErrRuleFailed,
+				ErrorValue: message,
+				Constraint: rule.Expr,
+			})
+		}
+	}
+	return validationErrors
+}
+
+func evaluateRule(rule model.Rule, env ruleEnv) (bool, error) {
+	if rule.When != "" {
+		applies, err := evaluateCondition(rule.When, env)
+		if err != nil || !applies {
+			return true, err
+		}
+	}
+	return evaluateCondition(rule.Expr, env)
+}
+
+func evaluateCondition(source string, env ruleEnv) (bool, error) {
+	expr, err := compileExpression(source)
+	if err != nil {
+		return false, err
+	}
+
+	value, err := expr.eval(env)
+	if err != nil {
+		return false, err
+	}
+	holds, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a boolean result, got %s", describeValue(value))
+	}
+	return holds, nil
+}
+
+func sectionValues(fields []Field) map[string]interface{} {
+	values := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		values[field.Name] = field.Value
+	}
+	return values
+}
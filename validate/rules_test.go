@@ -0,0 +1,111 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRules(t *testing.T) {
+	endpointModel := &model.EndpointModel{
+		Path:   "/tenants/{tenant}/bookings",
+		Method: "POST",
+		Rules: []model.Rule{
+			{Name: "dates", Expr: `date(body.end_date) > date(body.start_date)`, Message: "end_date must be after start_date"},
+			{Name: "sms", When: `body.contact_method == "sms"`, Expr: `present(body.phone)`},
+			{Name: "tenant", Expr: `headers["X-Tenant"] == body.tenant_id && path.tenant == body.tenant_id`},
+			{Expr: `query.limit <= 100`},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		endpoint *Endpoint
+		expected []ValidationError
+	}{
+		{
+			name: "Rules hold",
+			endpoint: &Endpoint{
+				Path:        "/tenants/acme/bookings",
+				Method:      "POST",
+				QueryParams: []Field{{Name: "limit", Value: "10"}},
+				Headers:     []Field{{Name: "X-Tenant", Value: "acme"}},
+				Body: []Field{
+					{Name: "tenant_id", Value: "acme"},
+					{Name: "start_date", Value: "01-02-2023"},
+					{Name: "end_date", Value: "02-02-2023"},
+					{Name: "contact_method", Value: "email"},
+				},
+			},
+		},
+		{
+			name: "Rules fail",
+			endpoint: &Endpoint{
+				Path:        "/tenants/acme/bookings",
+				Method:      "POST",
+				QueryParams: []Field{{Name: "limit", Value: "500"}},
+				Headers:     []Field{{Name: "X-Tenant", Value: "other"}},
+				Body: []Field{
+					{Name: "tenant_id", Value: "acme"},
+					{Name: "start_date", Value: "01-02-2023"},
+					{Name: "end_date", Value: "01-01-2023"},
+					{Name: "contact_method", Value: "sms"},
+				},
+			},
+			expected: []ValidationError{
+				{FieldName: "dates", Path: "/dates", ErrorType: ErrRuleFailed, ErrorValue: "end_date must be after start_date", Constraint: `date(body.end_date) > date(body.start_date)`},
+				{FieldName: "sms", Path: "/sms", ErrorType: ErrRuleFailed, ErrorValue: `present(body.phone)`, Constraint: `present(body.phone)`},
+				{FieldName: "tenant", Path: "/tenant", ErrorType: ErrRuleFailed, ErrorValue: `headers["X-Tenant"] == body.tenant_id && path.tenant == body.tenant_id`, Constraint: `headers["X-Tenant"] == body.tenant_id && path.tenant == body.tenant_id`},
+				{FieldName: "3", Path: "/3", ErrorType: ErrRuleFailed, ErrorValue: `query.limit <= 100`, Constraint: `query.limit <= 100`},
+			},
+		},
+		{
+			name: "Rules can't be evaluated",
+			endpoint: &Endpoint{
+				Path:        "/tenants/acme/bookings",
+				Method:      "POST",
+				QueryParams: []Field{{Name: "limit", Value: "ten"}},
+				Headers:     []Field{{Name: "X-Tenant", Value: "acme"}},
+				Body: []Field{
+					{Name: "tenant_id", Value: "acme"},
+					{Name: "start_date", Value: "01-02-2023"},
+					{Name: "end_date", Value: json.Number("20230202")},
+				},
+			},
+			expected: []ValidationError{
+				{FieldName: "dates", Path: "/dates", ErrorType: ErrRuleInvalid, ErrorValue: "date expects a string, got a number", Constraint: `date(body.end_date) > date(body.start_date)`},
+				{FieldName: "3", Path: "/3", ErrorType: ErrRuleInvalid, ErrorValue: "<= can't compare a string and a number", Constraint: `query.limit <= 100`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ValidateReport(tt.endpoint, endpointModel)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, report.Rules)
+		})
+	}
+
+	report := NewValidationReport().WithRules([]ValidationError{{FieldName: "dates", ErrorType: ErrRuleFailed}}).IsValid()
+	assert.False(t, report.Valid)
+}
+
+func TestLintRules(t *testing.T) {
+	models := []model.Endpoint{
+		{Path: "/a", Method: "GET", Rules: []model.Rule{{Name: "ok", Expr: `query.a == 1`}}},
+		{Path: "/b", Method: "POST", Rules: []model.Rule{
+			{Name: "empty"},
+			{Expr: `body.a ==`},
+			{Name: "when", When: `os.exit()`, Expr: `true`},
+		}},
+	}
+
+	problems := LintRules(models)
+	require.Len(t, problems, 3)
+	assert.Equal(t, model.Problem{Index: 1, Location: "POST /b rules/empty", Message: "missing expr"}, problems[0])
+	assert.Equal(t, "POST /b rules/1", problems[1].Location)
+	assert.Equal(t, "POST /b rules/when", problems[2].Location)
+}
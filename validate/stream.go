@@ -0,0 +1,80 @@
+package validate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode"
+)
+
+// EndpointDecoder streams endpoints from either a JSON array or a sequence of
+// JSON values such as a JSONL file, without loading the whole input in memory
+type EndpointDecoder struct {
+	reader  *bufio.Reader
+	decoder *json.Decoder
+	array   bool
+	index   int
+}
+
+func NewEndpointDecoder(r io.Reader) *EndpointDecoder {
+	return &EndpointDecoder{reader: bufio.NewReader(r)}
+}
+
+// Decode returns the next endpoint, or io.EOF when the input is exhausted
+func (d *EndpointDecoder) Decode() (*Endpoint, error) {
+	if d.decoder == nil {
+		if err := d.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	if d.array && !d.decoder.More() {
+		if _, err := d.decoder.Token(); err != nil {
+			return nil, fmt.Errorf("error decoding endpoint %d: %w", d.index, err)
+		}
+		return nil, io.EOF
+	}
+
+	var endpoint Endpoint
+	if err := d.decoder.Decode(&endpoint); err != nil {
+		if err == io.EOF && !d.array {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("error decoding endpoint %d: %w", d.index, err)
+	}
+	d.index++
+	return &endpoint, nil
+}
+
+// start detects whether the input is a JSON array by peeking at its first token
+func (d *EndpointDecoder) start() error {
+	for {
+		r, _, err := d.reader.ReadRune()
+		if err == io.EOF {
+			d.decoder = json.NewDecoder(d.reader)
+			return io.EOF
+		}
+		if err != nil {
+			return fmt.Errorf("error reading endpoints: %w", err)
+		}
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		if err := d.reader.UnreadRune(); err != nil {
+			return fmt.Errorf("error reading endpoints: %w", err)
+		}
+		d.array = r == '['
+		break
+	}
+
+	d.decoder = json.NewDecoder(d.reader)
+	d.decoder.UseNumber()
+	if d.array {
+		if _, err := d.decoder.Token(); err != nil {
+			return fmt.Errorf("error reading endpoints: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package validate
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointDecoder(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		paths       []string
+		expectedErr bool
+	}{
+		{
+			name:  "JSON array",
+			input: ` [{"path": "/users/info", "method": "GET"}, {"path": "/users/create", "method": "POST"}]`,
+			paths: []string{"/users/info", "/users/create"},
+		},
+		{
+			name:  "JSONL",
+			input: "{\"path\": \"/users/info\", \"method\": \"GET\"}\n{\"path\": \"/users/create\", \"method\": \"POST\"}\n",
+			paths: []string{"/users/info", "/users/create"},
+		},
+		{
+			name:  "Empty array",
+			input: "[]",
+		},
+		{
+			name:  "Empty input",
+			input: "\n",
+		},
+		{
+			name:        "Truncated array",
+			input:       `[{"path": "/users/info", "method": "GET"}`,
+			paths:       []string{"/users/info"},
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid endpoint",
+			input:       `{"path": {"path": "/users/info"}}`,
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoder := NewEndpointDecoder(strings.NewReader(tt.input))
+
+			var paths []string
+			for {
+				endpoint, err := decoder.Decode()
+				if errors.Is(err, io.EOF) {
+					require.False(t, tt.expectedErr)
+					break
+				}
+				if err != nil {
+					require.True(t, tt.expectedErr, err)
+					break
+				}
+				paths = append(paths, endpoint.Path)
+			}
+			require.Equal(t, tt.paths, paths)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package validate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/evgeniron/API-Validator/model"
+)
+
+// compiledTypes caches the validators of the stored type definitions by type
+// name. Stored definitions are never modified in place, a replaced definition is
+// a new one and its compiled entry replaces the old entry.
+var compiledTypes sync.Map
+
+type compiledEntry struct {
+	definition *model.TypeDefinition
+	validator  func(interface{}) bool
+}
+
+// CompileType builds the validator of a type definition. The value must pass the
+// base type, parse with the date layout and satisfy the constraints.
+func CompileType(definition *model.TypeDefinition) (func(interface{}) bool, error) {
+	if definition.Name == "" {
+		return nil, fmt.Errorf("missing type name")
+	}
+	if _, builtin := Validators[definition.Name]; builtin {
+		return nil, fmt.Errorf("type %q is a built-in type", definition.Name)
+	}
+
+	base, ok := Validators[definition.Base]
+	if !ok {
+		return nil, fmt.Errorf("type %q has unknown base type %q", definition.Name, definition.Base)
+	}
+
+	if definition.Pattern != "" {
+		if _, err := compilePattern(definition.Pattern); err != nil {
+			return nil, fmt.Errorf("type %q has an invalid pattern: %w", definition.Name, err)
+		}
+	}
+
+	constraints := definition.Constraints
+	layout := definition.DateLayout
+	return func(value interface{}) bool {
+		if !base(value) {
+			return false
+		}
+
+		if layout != "" {
+			text, ok := value.(string)
+			if !ok {
+				return false
+			}
+			if _, err := time.Parse(layout, text); err != nil {
+				return false
+			}
+		}
+
+		return len(validateConstraints("", Field{Value: value}, value, &constraints)) == 0
+	}, nil
+}
+
+func compiledType(definition *model.TypeDefinition) (func(interface{}) bool, error) {
+	if entry, ok := compiledTypes.Load(definition.Name); ok && entry.(compiledEntry).definition == definition {
+		return entry.(compiledEntry).validator, nil
+	}
+
+	validator, err := CompileType(definition)
+	if err != nil {
+		return nil, err
+	}
+	compiledTypes.Store(definition.Name, compiledEntry{definition: definition, validator: validator})
+	return validator, nil
+}
+
+// typeResolver finds the validator of a type name, the built-in validators come
+// first and the type definitions are read from db
+type typeResolver struct {
+	db model.Reader
+}
+
+// lookup returns the validator of the type and its built-in base type
+func (tr typeResolver) lookup(name string) (func(interface{}) bool, string, bool) {
+	if validator, ok := Validators[name]; ok {
+		return validator, name, true
+	}
+	if tr.db == nil {
+		return nil, "", false
+	}
+
+	definition, err := model.GetType(tr.db, name)
+	if err != nil {
+		return nil, "", false
+	}
+	validator, err := compiledType(definition)
+	if err != nil {
+		// log error here
+		return nil, "", false
+	}
+	return validator, definition.Base, true
+}
+
+// KnownTypeIn reports whether fields of the named type can be validated, either
+// by a built-in validator or by a type definition stored in db
+func KnownTypeIn(db model.Reader) func(name string) bool {
+	return func(name string) bool {
+		_, _, ok := typeResolver{db: db}.lookup(name)
+		return ok
+	}
+}
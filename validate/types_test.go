@@ -0,0 +1,139 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/evgeniron/API-Validator/model"
+	"github.com/evgeniron/API-Validator/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileType(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition model.TypeDefinition
+		valid      []interface{}
+		invalid    []interface{}
+	}{
+		{
+			name:       "Pattern and length",
+			definition: model.TypeDefinition{Name: "SKU", Base: "String", Constraints: model.Constraints{Pattern: "^[A-Z]{3}-[0-9]+$", MaxLength: intPtr(8)}},
+			valid:      []interface{}{"ABC-1", "XYZ-1234"},
+			invalid:    []interface{}{"abc-1", "ABC-12345", 42},
+		},
+		{
+			name:       "Enum",
+			definition: model.TypeDefinition{Name: "Country", Base: "String", Constraints: model.Constraints{Enum: []interface{}{"US", "DE", "IL"}}},
+			valid:      []interface{}{"IL"},
+			invalid:    []interface{}{"FR", ""},
+		},
+		{
+			name:       "Date layout",
+			definition: model.TypeDefinition{Name: "USDate", Base: "String", DateLayout: "01/02/2006"},
+			valid:      []interface{}{"02/28/2023"},
+			invalid:    []interface{}{"28-02-2023", "02/30/2023"},
+		},
+		{
+			name:       "Numeric base",
+			definition: model.TypeDefinition{Name: "Percent", Base: "Int", Constraints: model.Constraints{Minimum: "0", Maximum: "100"}},
+			valid:      []interface{}{json.Number("0"), json.Number("100")},
+			invalid:    []interface{}{json.Number("101"), json.Number("50.5"), "50"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := CompileType(&tt.definition)
+			require.NoError(t, err)
+			for _, value := range tt.valid {
+				assert.True(t, validator(value), "%v", value)
+			}
+			for _, value := range tt.invalid {
+				assert.False(t, validator(value), "%v", value)
+			}
+		})
+	}
+}
+
+func TestCompileTypeErrors(t *testing.T) {
+	definitions := []model.TypeDefinition{
+		{Base: "String"},
+		{Name: "String", Base: "String"},
+		{Name: "SKU", Base: "Text"},
+		{Name: "SKU", Base: "String", Constraints: model.Constraints{Pattern: "([A-Z]"}},
+	}
+	for _, definition := range definitions {
+		_, err := CompileType(&definition)
+		assert.Error(t, err, "%+v", definition)
+	}
+}
+
+func TestValidateReportWithTypes(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+	require.NoError(t, model.StoreTypes(db, []model.TypeDefinition{
+		{Name: "Percent", Base: "Int", Constraints: model.Constraints{Minimum: "0", Maximum: "100"}},
+		{Name: "SKU", Base: "String", Constraints: model.Constraints{Pattern: "^[A-Z]{3}-[0-9]+$"}},
+	}))
+
+	endpointModel := &model.EndpointModel{
+		Path:        "/items",
+		Method:      "GET",
+		QueryParams: map[string]model.FieldModel{"discount": {Types: []string{"Percent"}}},
+		Body:        map[string]model.FieldModel{"sku": {Types: []string{"SKU"}}},
+	}
+
+	report, err := ValidateReportWithTypes(&Endpoint{
+		Path:        "/items",
+		Method:      "GET",
+		QueryParams: []Field{{Name: "discount", Value: "15"}},
+		Body:        []Field{{Name: "sku", Value: "ABC-1"}},
+	}, endpointModel, db.Snapshot())
+	require.NoError(t, err)
+	assert.True(t, report.Valid)
+
+	report, err = ValidateReportWithTypes(&Endpoint{
+		Path:        "/items",
+		Method:      "GET",
+		QueryParams: []Field{{Name: "discount", Value: "150"}},
+		Body:        []Field{{Name: "sku", Value: "abc"}},
+	}, endpointModel, db.Snapshot())
+	require.NoError(t, err)
+	assert.Equal(t, []ValidationError{
+		{FieldName: "discount", Path: "/discount", ErrorType: ErrMismatchType, ExpectedType: "Percent", ErrorValue: "150"},
+	}, report.QueryParams)
+	assert.Equal(t, []ValidationError{
+		{FieldName: "sku", Path: "/sku", ErrorType: ErrMismatchType, ExpectedType: "SKU", ErrorValue: "abc"},
+	}, report.Body)
+
+	// without the stored types the fields can't be validated
+	report, err = ValidateReport(&Endpoint{Path: "/items", Method: "GET", Body: []Field{{Name: "sku", Value: "abc"}}}, endpointModel)
+	require.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.True(t, KnownTypeIn(db.Snapshot())("SKU"))
+	assert.False(t, KnownTypeIn(db.Snapshot())("Barcode"))
+}
+
+func TestCompiledTypeReplaced(t *testing.T) {
+	db, err := store.NewInMemoryDB()
+	require.NoError(t, err)
+
+	for _, maximum := range []json.Number{"20", "30"} {
+		require.NoError(t, model.StoreTypes(db, []model.TypeDefinition{
+			{Name: "Score", Base: "Int", Constraints: model.Constraints{Maximum: maximum}},
+		}))
+
+		validator, base, ok := typeResolver{db: db}.lookup("Score")
+		require.True(t, ok)
+		assert.Equal(t, "Int", base)
+		assert.Equal(t, maximum == "30", validator(json.Number("25")))
+	}
+
+	// the cache holds the current definition only
+	definition, err := model.GetType(db, "Score")
+	require.NoError(t, err)
+	entry, ok := compiledTypes.Load("Score")
+	require.True(t, ok)
+	assert.Same(t, definition, entry.(compiledEntry).definition)
+}
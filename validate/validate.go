@@ -1,8 +1,12 @@
 package validate
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/evgeniron/API-Validator/model"
 	"github.com/evgeniron/API-Validator/utils"
@@ -25,21 +29,35 @@ type Endpoint struct {
 	QueryParams []Field `json:"query_params"`
 	Headers     []Field `json:"headers"`
 	Body        []Field `json:"body"`
+	// ContentType is the Content-Type header of the request, checked against the
+	// content type of the model when set
+	ContentType string `json:"content_type,omitempty"`
+	// wireBody is set for bodies whose values are strings on the wire, e.g. forms
+	wireBody bool
 }
 
+// ValidationError describes a single violation. Path is the JSON pointer of the
+// offending field relative to its report section, e.g. "/address/city".
+// Constraint holds the violated constraint value for constraint errors, and
+// Branches the failed branches for combinator errors.
 type ValidationError struct {
 	FieldName    string
+	Path         string
 	ErrorType    string
 	ExpectedType string
 	ErrorValue   interface{}
+	Constraint   interface{}   `json:",omitempty"`
+	Branches     []BranchError `json:",omitempty"`
 }
 
 type ValidationReport struct {
 	Path        string
 	Method      string
+	PathParams  []ValidationError
 	QueryParams []ValidationError
 	Headers     []ValidationError
 	Body        []ValidationError
+	Rules       []ValidationError
 	Valid       bool
 }
 
@@ -65,6 +83,15 @@ func (vr *ValidationReport) WithMethod(method string) *ValidationReport {
 	return vr
 }
 
+func (vr *ValidationReport) WithPathParams(pathParams []ValidationError) *ValidationReport {
+	if vr == nil {
+		return nil
+	}
+
+	vr.PathParams = pathParams
+	return vr
+}
+
 func (vr *ValidationReport) WithQueryParams(queryParams []ValidationError) *ValidationReport {
 	if vr == nil {
 		return nil
@@ -92,12 +119,21 @@ func (vr *ValidationReport) WithBody(body []ValidationError) *ValidationReport {
 	return vr
 }
 
+func (vr *ValidationReport) WithRules(rules []ValidationError) *ValidationReport {
+	if vr == nil {
+		return nil
+	}
+
+	vr.Rules = rules
+	return vr
+}
+
 func (vr *ValidationReport) IsValid() *ValidationReport {
 	if vr == nil {
 		return nil
 	}
 
-	if len(vr.QueryParams) == 0 && len(vr.Headers) == 0 && len(vr.Body) == 0 {
+	if len(vr.PathParams) == 0 && len(vr.QueryParams) == 0 && len(vr.Headers) == 0 && len(vr.Body) == 0 && len(vr.Rules) == 0 {
 		vr.Valid = true
 	}
 	return vr
@@ -114,13 +150,32 @@ func ParseEndpoint(r *http.Request) (*Endpoint, error) {
 	return &endpoint, nil
 }
 
-func ValidateReport(endpoint *Endpoint, model *model.EndpointModel) (*ValidationReport, error) {
+// ValidateReport validates the endpoint against its model with the built-in types
+func ValidateReport(endpoint *Endpoint, endpointModel *model.EndpointModel) (*ValidationReport, error) {
+	return ValidateReportWithTypes(endpoint, endpointModel, nil)
+}
+
+// ValidateReportWithTypes validates the endpoint against its model, resolving the
+// types without a built-in validator from the type definitions stored in db
+func ValidateReportWithTypes(endpoint *Endpoint, endpointModel *model.EndpointModel, db model.Reader) (*ValidationReport, error) {
+	types := typeResolver{db: db}
+	wireValidator := sectionValidator{wireStrings: true, types: types}
+	jsonValidator := sectionValidator{types: types}
+	pathParams := pathParamFields(endpoint.Path, endpointModel.Path)
+	bodyValidator := jsonValidator
+	if endpoint.wireBody {
+		bodyValidator = wireValidator
+	}
+
 	validationReport := NewValidationReport().
 		WithPath(endpoint.Path).
 		WithMethod(endpoint.Method).
-		WithQueryParams(validateFields(endpoint.QueryParams, model.QueryParams)).
-		WithHeaders(validateFields(endpoint.Headers, model.Headers)).
-		WithBody(validateFields(endpoint.Body, model.Body)).IsValid()
+		WithPathParams(wireValidator.validateFields("", pathParams, endpointModel.PathParams, sectionRequirements(endpointModel, "path_params"))).
+		WithQueryParams(wireValidator.validateFields("", endpoint.QueryParams, endpointModel.QueryParams, sectionRequirements(endpointModel, "query_params"))).
+		WithHeaders(append(validateContentType(endpoint, endpointModel.ContentType),
+			wireValidator.validateFields("", endpoint.Headers, endpointModel.Headers, sectionRequirements(endpointModel, "headers"))...)).
+		WithBody(bodyValidator.validateFields("", endpoint.Body, endpointModel.Body, sectionRequirements(endpointModel, "body"))).
+		WithRules(validateRules(endpoint, pathParams, endpointModel.Rules)).IsValid()
 
 	if validationReport == nil {
 		return nil, fmt.Errorf("failed to construct report")
@@ -128,39 +183,164 @@ func ValidateReport(endpoint *Endpoint, model *model.EndpointModel) (*Validation
 	return validationReport, nil
 }
 
-func validateFieldType(inputField Field, types []string) []ValidationError {
+func sectionRequirements(endpointModel *model.EndpointModel, section string) *model.Requirements {
+	requirements, ok := endpointModel.Requirements[section]
+	if !ok {
+		return nil
+	}
+	return &requirements
+}
+
+// pathParamFields extracts the path parameter values captured by the model path template
+func pathParamFields(path, template string) []Field {
+	params, ok := model.MatchPath(template, path)
+	if !ok {
+		return nil
+	}
+
+	fields := make([]Field, 0, len(params))
+	for name, value := range params {
+		fields = append(fields, Field{Name: name, Value: value})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// fieldPointer returns the JSON pointer (RFC 6901) of the named field inside parent
+func fieldPointer(parent, name string) string {
+	return parent + "/" + pointerEscaper.Replace(name)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// sectionValidator validates the fields of a report section
+type sectionValidator struct {
+	// wireStrings is set for sections whose values are strings on the wire,
+	// numeric and Boolean types then also accept the strings spelling them
+	wireStrings bool
+	types       typeResolver
+}
+
+func (sv sectionValidator) validateFieldType(parent string, inputField Field, types []string) []ValidationError {
 	var validationErrors []ValidationError
 	for _, filterType := range types {
-		validatorFunc, validatorExists := Validators[filterType]
+		validatorFunc, base, validatorExists := sv.types.lookup(filterType)
 		if !validatorExists {
 			// log error here
 			continue
 		}
 
-		if !validatorFunc(inputField.Value) {
-			validationErrors = append(validationErrors, ValidationError{inputField.Name, ErrMismatchType, filterType, inputField.Value})
+		value := inputField.Value
+		if text, ok := value.(string); ok && sv.wireStrings {
+			value = wireValue(base, text)
+		}
+
+		if !validatorFunc(value) {
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName:    inputField.Name,
+				Path:         fieldPointer(parent, inputField.Name),
+				ErrorType:    ErrMismatchType,
+				ExpectedType: filterType,
+				ErrorValue:   inputField.Value,
+			})
 		}
 	}
 
 	return validationErrors
 }
 
-func validateField(inputField Field, expectedFieldModels map[string]model.FieldModel) []ValidationError {
+// constraintValue returns the value as compared by the constraints, strings of
+// wire sections compare as numbers or booleans when the field has a numeric or
+// Boolean type and the string spells one
+func (sv sectionValidator) constraintValue(value interface{}, types []string) interface{} {
+	text, ok := value.(string)
+	if !ok || !sv.wireStrings {
+		return value
+	}
+
+	for _, typeName := range types {
+		_, base, _ := sv.types.lookup(typeName)
+		if !NumericTypes[base] && base != "Boolean" {
+			continue
+		}
+		if number, ok := wireValue(base, text).(json.Number); ok && !numberPattern.MatchString(number.String()) {
+			return value
+		}
+		return wireValue(base, text)
+	}
+	return value
+}
+
+// wireNumber reads a numeric string of a wire section as a number
+func wireNumber(text string) json.Number {
+	return json.Number(strings.TrimSpace(text))
+}
+
+func (sv sectionValidator) validateField(parent string, inputField Field, expectedFieldModels map[string]model.FieldModel) []ValidationError {
 	var validationErrors []ValidationError
 
 	expectedFieldModel, fieldModelExists := expectedFieldModels[inputField.Name]
 	if !fieldModelExists {
-		validationErrors = append(validationErrors, ValidationError{inputField.Name, ErrUnrecognizedField, "", nil})
+		validationErrors = append(validationErrors, ValidationError{
+			FieldName: inputField.Name,
+			Path:      fieldPointer(parent, inputField.Name),
+			ErrorType: ErrUnrecognizedField,
+		})
 		return validationErrors
 	}
 
-	validationErrors = append(validationErrors, validateFieldType(inputField, expectedFieldModel.Types)...)
+	validationErrors = append(validationErrors, sv.validateValue(parent, inputField, expectedFieldModel)...)
 
 	return validationErrors
 }
 
-func validateRequiredFields(expectedFieldModels map[string]model.FieldModel, existingFields map[string]struct{}) []ValidationError {
+// validateValue validates the field value against its model, walking into object
+// properties and list items when the model describes them
+func (sv sectionValidator) validateValue(parent string, inputField Field, fieldModel model.FieldModel) []ValidationError {
+	validationErrors := sv.validateFieldType(parent, inputField, fieldModel.Types)
+	pointer := fieldPointer(parent, inputField.Name)
+
+	if fieldModel.Constraints != nil {
+		value := sv.constraintValue(inputField.Value, fieldModel.Types)
+		validationErrors = append(validationErrors, validateConstraints(parent, inputField, value, fieldModel.Constraints)...)
+	}
+
+	validationErrors = append(validationErrors, sv.validateCombinators(parent, inputField, fieldModel)...)
+
+	if fieldModel.Properties != nil {
+		if object, ok := inputField.Value.(map[string]interface{}); ok {
+			validationErrors = append(validationErrors, sv.validateFields(pointer, objectFields(object), fieldModel.Properties, fieldModel.Requirements)...)
+		}
+	}
+
+	if fieldModel.Items != nil {
+		if items, ok := inputField.Value.([]interface{}); ok {
+			for i, item := range items {
+				itemField := Field{Name: strconv.Itoa(i), Value: item}
+				validationErrors = append(validationErrors, sv.validateValue(pointer, itemField, *fieldModel.Items)...)
+			}
+		}
+	}
+
+	return validationErrors
+}
+
+// objectFields converts a decoded JSON object into fields ordered by name
+func objectFields(object map[string]interface{}) []Field {
+	fields := make([]Field, 0, len(object))
+	for name, value := range object {
+		fields = append(fields, Field{Name: name, Value: value})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// validateRequiredFields reports the required fields missing from the existing
+// fields, which map the field names to their values, followed by the violated
+// requirements
+func validateRequiredFields(parent string, expectedFieldModels map[string]model.FieldModel, requirements *model.Requirements, existingFields map[string]interface{}, wire bool) []ValidationError {
 	var validationErrors []ValidationError
+	missing := make(map[string]bool)
 	for fieldName, fieldModel := range expectedFieldModels {
 		if !fieldModel.Required {
 			continue
@@ -168,22 +348,31 @@ func validateRequiredFields(expectedFieldModels map[string]model.FieldModel, exi
 
 		_, fieldExists := existingFields[fieldName]
 		if !fieldExists {
-			validationErrors = append(validationErrors, ValidationError{fieldName, ErrMissingRequiredField, "", nil})
+			missing[fieldName] = true
+			validationErrors = append(validationErrors, ValidationError{
+				FieldName: fieldName,
+				Path:      fieldPointer(parent, fieldName),
+				ErrorType: ErrMissingRequiredField,
+			})
 		}
 	}
+
+	if requirements != nil {
+		validationErrors = append(validationErrors, validateRequirements(parent, requirements, existingFields, missing, wire)...)
+	}
 	return validationErrors
 }
 
-func validateFields(inputFields []Field, expectedFieldModels map[string]model.FieldModel) []ValidationError {
+func (sv sectionValidator) validateFields(parent string, inputFields []Field, expectedFieldModels map[string]model.FieldModel, requirements *model.Requirements) []ValidationError {
 	var validationErrors []ValidationError
 
-	existingFields := make(map[string]struct{}, len(inputFields))
+	existingFields := make(map[string]interface{}, len(inputFields))
 	for _, inputField := range inputFields {
-		existingFields[inputField.Name] = struct{}{}
-		validationErrors = append(validationErrors, validateField(inputField, expectedFieldModels)...)
+		existingFields[inputField.Name] = inputField.Value
+		validationErrors = append(validationErrors, sv.validateField(parent, inputField, expectedFieldModels)...)
 	}
 
-	validationErrors = append(validationErrors, validateRequiredFields(expectedFieldModels, existingFields)...)
+	validationErrors = append(validationErrors, validateRequiredFields(parent, expectedFieldModels, requirements, existingFields, sv.wireStrings)...)
 
 	return validationErrors
 }
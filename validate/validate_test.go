@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/evgeniron/API-Validator/model"
@@ -19,7 +20,7 @@ func TestValidateFieldType(t *testing.T) {
 			name:       "Value Type Mismatch",
 			inputField: Field{Name: "age", Value: "25"},
 			types:      []string{"Int"},
-			expected:   []ValidationError{{FieldName: "age", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: "25"}},
+			expected:   []ValidationError{{FieldName: "age", Path: "/age", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: "25"}},
 		},
 		{
 			name:       "No validation errors",
@@ -31,7 +32,7 @@ func TestValidateFieldType(t *testing.T) {
 			name:       "Boolean value mismatch type",
 			inputField: Field{Name: "flag", Value: "True"},
 			types:      []string{"Boolean"},
-			expected:   []ValidationError{{FieldName: "flag", ErrorType: ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "True"}},
+			expected:   []ValidationError{{FieldName: "flag", Path: "/flag", ErrorType: ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "True"}},
 		},
 		{
 			name:       "No validation errors for boolean",
@@ -43,7 +44,7 @@ func TestValidateFieldType(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Call validateFieldType function with given input field and types
-			actual := validateFieldType(tt.inputField, tt.types)
+			actual := sectionValidator{}.validateFieldType("", tt.inputField, tt.types)
 
 			// Check if the result matches with expected output
 			if len(actual) != len(tt.expected) {
@@ -51,7 +52,7 @@ func TestValidateFieldType(t *testing.T) {
 			}
 
 			for i := 0; i < len(actual); i++ {
-				if actual[i] != tt.expected[i] {
+				if !reflect.DeepEqual(actual[i], tt.expected[i]) {
 					t.Errorf("Expected %v but got %v", tt.expected[i], actual[i])
 				}
 			}
@@ -75,7 +76,7 @@ func TestValidateField(t *testing.T) {
 				"age":    {Types: []string{"Int"}, Required: true},
 				"gender": {Types: []string{"String"}, Required: false},
 			},
-			expected: []ValidationError{{FieldName: "age", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: "25"}},
+			expected: []ValidationError{{FieldName: "age", Path: "/age", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: "25"}},
 		},
 		{
 			name:       "Only Value Type Mismatch",
@@ -84,7 +85,7 @@ func TestValidateField(t *testing.T) {
 				"age":    {Types: []string{"Int"}, Required: true},
 				"gender": {Types: []string{"String"}, Required: true},
 			},
-			expected: []ValidationError{{FieldName: "age", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: "25"}},
+			expected: []ValidationError{{FieldName: "age", Path: "/age", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: "25"}},
 		},
 		{
 			name:       "No validation error",
@@ -98,7 +99,7 @@ func TestValidateField(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Call validateField function with given input field and expected models
-			actual := validateField(tt.inputField, tt.expectedModels)
+			actual := sectionValidator{}.validateField("", tt.inputField, tt.expectedModels)
 
 			// Check if the result matches with expected output
 			if len(actual) != len(tt.expected) {
@@ -106,7 +107,7 @@ func TestValidateField(t *testing.T) {
 			}
 
 			for i := 0; i < len(actual); i++ {
-				if actual[i] != tt.expected[i] {
+				if !reflect.DeepEqual(actual[i], tt.expected[i]) {
 					t.Errorf("Expected %v but got %v", tt.expected[i], actual[i])
 				}
 			}
@@ -118,7 +119,7 @@ func TestValidateRequiredFields(t *testing.T) {
 	tests := []struct {
 		name           string
 		fieldModels    map[string]model.FieldModel
-		existingFields map[string]struct{}
+		existingFields map[string]interface{}
 		expected       []ValidationError
 	}{
 		{
@@ -128,9 +129,9 @@ func TestValidateRequiredFields(t *testing.T) {
 				"field2": {Required: true},
 				"field3": {Required: false},
 			},
-			existingFields: map[string]struct{}{
-				"field1": {},
-				"field2": {},
+			existingFields: map[string]interface{}{
+				"field1": nil,
+				"field2": nil,
 			},
 			expected: []ValidationError{},
 		},
@@ -141,11 +142,11 @@ func TestValidateRequiredFields(t *testing.T) {
 				"field2": {Types: []string{"Int"}, Required: true},
 				"field3": {Types: []string{"Int"}, Required: false},
 			},
-			existingFields: map[string]struct{}{
-				"field1": {},
+			existingFields: map[string]interface{}{
+				"field1": nil,
 			},
 			expected: []ValidationError{
-				{FieldName: "field2", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
+				{FieldName: "field2", Path: "/field2", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
 			},
 		},
 		{
@@ -155,17 +156,17 @@ func TestValidateRequiredFields(t *testing.T) {
 				"field2": {Required: true},
 				"field3": {Required: true},
 			},
-			existingFields: map[string]struct{}{},
+			existingFields: map[string]interface{}{},
 			expected: []ValidationError{
-				{FieldName: "field1", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
-				{FieldName: "field2", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
-				{FieldName: "field3", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
+				{FieldName: "field1", Path: "/field1", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
+				{FieldName: "field2", Path: "/field2", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
+				{FieldName: "field3", Path: "/field3", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			actual := validateRequiredFields(tt.fieldModels, tt.existingFields)
+			actual := validateRequiredFields("", tt.fieldModels, nil, tt.existingFields, false)
 
 			// Check if the result matches with expected output
 			if len(actual) != len(tt.expected) {
@@ -182,7 +183,7 @@ func TestValidateRequiredFields(t *testing.T) {
 				if !ok {
 					t.Errorf("Expected %v but it is missing", element)
 				}
-				if actualResult != element {
+				if !reflect.DeepEqual(actualResult, element) {
 					t.Errorf("Expected %v but got %v", element, actualResult)
 				}
 			}
@@ -209,7 +210,7 @@ func TestValidateFields(t *testing.T) {
 				"field4": {Types: []string{"UUID"}, Required: true},
 			},
 			expectedErrors: []ValidationError{
-				{FieldName: "field4", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
+				{FieldName: "field4", Path: "/field4", ErrorType: ErrMissingRequiredField, ExpectedType: "", ErrorValue: nil},
 			},
 		},
 		{
@@ -224,16 +225,16 @@ func TestValidateFields(t *testing.T) {
 				"field3": {Types: []string{"Boolean"}, Required: true},
 			},
 			expectedErrors: []ValidationError{
-				{FieldName: "field1", ErrorType: ErrMismatchType, ExpectedType: "String", ErrorValue: 42},
-				{FieldName: "field2", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: true},
-				{FieldName: "field3", ErrorType: ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "hello world"},
+				{FieldName: "field1", Path: "/field1", ErrorType: ErrMismatchType, ExpectedType: "String", ErrorValue: 42},
+				{FieldName: "field2", Path: "/field2", ErrorType: ErrMismatchType, ExpectedType: "Int", ErrorValue: true},
+				{FieldName: "field3", Path: "/field3", ErrorType: ErrMismatchType, ExpectedType: "Boolean", ErrorValue: "hello world"},
 			},
 		},
 		{
 			inputFields: []Field{
 				{Name: "field1", Value: 42},
 				{Name: "field2", Value: true},
-				{Name: "field3", Value: "hello world"},
+				{Name: "field3", Value: "hello@world.test"},
 			},
 			expectedFieldModels: map[string]model.FieldModel{
 				"field1": {Types: []string{"Int"}, Required: true},
@@ -245,7 +246,114 @@ func TestValidateFields(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		validationErrors := validateFields(test.inputFields, test.expectedFieldModels)
+		validationErrors := sectionValidator{}.validateFields("", test.inputFields, test.expectedFieldModels, nil)
 		assert.Equal(t, test.expectedErrors, validationErrors)
 	}
 }
+
+func TestValidateReportPathParams(t *testing.T) {
+	endpointModel := model.NewModel().
+		WithPath("/users/{id}/orders/{order_id}").
+		WithMethod("GET").
+		WithPathParams([]model.Field{
+			{Name: "id", Types: []string{"String"}, Required: true},
+			{Name: "order_id", Types: []string{"UUID"}, Required: true},
+		})
+
+	tests := []struct {
+		name     string
+		path     string
+		expected []ValidationError
+	}{
+		{
+			name:     "Valid path params",
+			path:     "/users/john/orders/56ee9b7a-da8e-45a1-aade-a57761b912c4",
+			expected: nil,
+		},
+		{
+			name: "Invalid path param",
+			path: "/users/john/orders/latest",
+			expected: []ValidationError{
+				{FieldName: "order_id", Path: "/order_id", ErrorType: ErrMismatchType, ExpectedType: "UUID", ErrorValue: "latest"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ValidateReport(&Endpoint{Path: tt.path, Method: "GET"}, endpointModel)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, report.PathParams)
+			assert.Equal(t, tt.expected == nil, report.Valid)
+		})
+	}
+}
+
+func TestValidateNestedFields(t *testing.T) {
+	fieldModels := map[string]model.FieldModel{
+		"address": {
+			Types:    []string{"Object"},
+			Required: true,
+			Properties: map[string]model.FieldModel{
+				"city":   {Types: []string{"String"}, Required: true},
+				"zip/id": {Types: []string{"String"}},
+			},
+		},
+		"items": {
+			Types: []string{"List"},
+			Items: &model.FieldModel{
+				Types: []string{"Object"},
+				Properties: map[string]model.FieldModel{
+					"sku":   {Types: []string{"UUID"}, Required: true},
+					"count": {Types: []string{"Int"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		fields   []Field
+		expected []ValidationError
+	}{
+		{
+			name: "Valid nested fields",
+			fields: []Field{
+				{Name: "address", Value: map[string]interface{}{"city": "Tel Aviv", "zip/id": "1234"}},
+				{Name: "items", Value: []interface{}{
+					map[string]interface{}{"sku": "56ee9b7a-da8e-45a1-aade-a57761b912c4", "count": 1},
+				}},
+			},
+			expected: nil,
+		},
+		{
+			name: "Invalid nested fields",
+			fields: []Field{
+				{Name: "address", Value: map[string]interface{}{"zip/id": 1234, "street": "Test Road"}},
+				{Name: "items", Value: []interface{}{
+					map[string]interface{}{"sku": "56ee9b7a-da8e-45a1-aade-a57761b912c4"},
+					map[string]interface{}{"sku": "d9b96787786b", "count": 1},
+					"d9b96787786b",
+				}},
+			},
+			expected: []ValidationError{
+				{FieldName: "street", Path: "/address/street", ErrorType: ErrUnrecognizedField},
+				{FieldName: "zip/id", Path: "/address/zip~1id", ErrorType: ErrMismatchType, ExpectedType: "String", ErrorValue: 1234},
+				{FieldName: "city", Path: "/address/city", ErrorType: ErrMissingRequiredField},
+				{FieldName: "sku", Path: "/items/1/sku", ErrorType: ErrMismatchType, ExpectedType: "UUID", ErrorValue: "d9b96787786b"},
+				{FieldName: "2", Path: "/items/2", ErrorType: ErrMismatchType, ExpectedType: "Object", ErrorValue: "d9b96787786b"},
+			},
+		},
+		{
+			name:   "Missing nested object",
+			fields: []Field{},
+			expected: []ValidationError{
+				{FieldName: "address", Path: "/address", ErrorType: ErrMissingRequiredField},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sectionValidator{}.validateFields("", tt.fields, fieldModels, nil))
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package validate
 
-import "testing"
+import (
+	"encoding/json"
+	"testing"
+)
 
 func TestEmailValidator(t *testing.T) {
 	tests := []struct {
@@ -28,6 +31,16 @@ func TestIntValidator(t *testing.T) {
 		expected bool
 	}{
 		{1, true},
+		{float64(1), true},
+		{1.5, false},
+		{json.Number("42"), true},
+		{json.Number("-7"), true},
+		{json.Number("1.0"), true},
+		{json.Number("1e3"), true},
+		{json.Number("1.5"), false},
+		{json.Number("9223372036854775808"), false},
+		{json.Number("1e400"), false},
+		{json.Number("abc"), false},
 		{"1", false},
 		{true, false},
 		{"", false},
@@ -41,6 +54,91 @@ func TestIntValidator(t *testing.T) {
 	}
 }
 
+func TestInt32Validator(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{json.Number("2147483647"), true},
+		{json.Number("-2147483648"), true},
+		{json.Number("2147483648"), false},
+		{json.Number("-2147483649"), false},
+		{int64(1), true},
+		{"1", false},
+	}
+
+	for _, test := range tests {
+		result := Int32Validator(test.value)
+		if result != test.expected {
+			t.Errorf("Int32Validator(%v) = %v, expected %v", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestInt64Validator(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{json.Number("9223372036854775807"), true},
+		{json.Number("-9223372036854775808"), true},
+		{json.Number("9223372036854775808"), false},
+		{uint64(1 << 63), false},
+		{json.Number("2.5"), false},
+	}
+
+	for _, test := range tests {
+		result := Int64Validator(test.value)
+		if result != test.expected {
+			t.Errorf("Int64Validator(%v) = %v, expected %v", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestFloatValidator(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{json.Number("1.5"), true},
+		{json.Number("-3"), true},
+		{json.Number("6.02e23"), true},
+		{json.Number("1e400"), false},
+		{2.5, true},
+		{7, true},
+		{"1.5", false},
+		{false, false},
+	}
+
+	for _, test := range tests {
+		result := FloatValidator(test.value)
+		if result != test.expected {
+			t.Errorf("FloatValidator(%v) = %v, expected %v", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestDecimalValidator(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{json.Number("0.1"), true},
+		{json.Number("123456789012345678901234567890.123456789"), true},
+		{json.Number("1e400"), true},
+		{json.Number("01"), false},
+		{json.Number(".5"), false},
+		{"0.1", false},
+	}
+
+	for _, test := range tests {
+		result := DecimalValidator(test.value)
+		if result != test.expected {
+			t.Errorf("DecimalValidator(%v) = %v, expected %v", test.value, result, test.expected)
+		}
+	}
+}
+
 func TestStringValidator(t *testing.T) {
 	tests := []struct {
 		value    interface{}
@@ -102,6 +200,25 @@ func TestJSONValidator(t *testing.T) {
 	}
 }
 
+func TestObjectValidator(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{map[string]interface{}{"city": "Tel Aviv"}, true},
+		{map[string]interface{}{}, true},
+		{[]interface{}{1, 2, 3}, false},
+		{"{}", false},
+	}
+
+	for _, test := range tests {
+		result := ObjectValidator(test.value)
+		if result != test.expected {
+			t.Errorf("ObjectValidator(%v) = %v, expected %v", test.value, result, test.expected)
+		}
+	}
+}
+
 func TestDateValidator(t *testing.T) {
 	validDate := "02-01-2023"
 	invalidDate := "31-02-2023"
@@ -114,3 +231,71 @@ func TestDateValidator(t *testing.T) {
 		t.Errorf("Expected invalid date to return false, got true")
 	}
 }
+
+func TestISODateValidator(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{"2024-05-01", true},
+		{"2024-02-30", false},
+		{"01-05-2024", false},
+		{"2024-05-01T10:00:00Z", false},
+		{20240501, false},
+	}
+
+	for _, test := range tests {
+		result := ISODateValidator(test.value)
+		if result != test.expected {
+			t.Errorf("ISODateValidator(%v) = %v, expected %v", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestFileValidator(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected bool
+	}{
+		{FileValue("avatar.png", "image/png", 1024), true},
+		{map[string]interface{}{"filename": "a.txt", "content_type": "text/plain", "size": float64(3)}, true},
+		{map[string]interface{}{"filename": "a.txt", "content_type": "text/plain", "size": json.Number("-1")}, false},
+		{map[string]interface{}{"filename": "a.txt", "size": json.Number("3")}, false},
+		{map[string]interface{}{"content_type": "text/plain", "size": json.Number("3")}, false},
+		{"avatar.png", false},
+	}
+
+	for _, test := range tests {
+		result := FileValidator(test.value)
+		if result != test.expected {
+			t.Errorf("FileValidator(%v) = %v, expected %v", test.value, result, test.expected)
+		}
+	}
+}
+
+func TestMatchesType(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		wire     bool
+		expected bool
+	}{
+		{"Int", json.Number("42"), false, true},
+		{"Int", "42", false, false},
+		{"Int", "42", true, true},
+		{"Decimal", " 4.5 ", true, true},
+		{"Int", "4.5", true, false},
+		{"String", "42", true, true},
+		{"Boolean", "true", true, true},
+		{"Boolean", "true", false, false},
+		{"Boolean", "yes", true, false},
+		{"Barcode", "42", false, false},
+	}
+
+	for _, test := range tests {
+		result := MatchesType(test.name, test.value, test.wire)
+		if result != test.expected {
+			t.Errorf("MatchesType(%s, %v, %v) = %v, expected %v", test.name, test.value, test.wire, result, test.expected)
+		}
+	}
+}
@@ -1,20 +1,71 @@
 package validate
 
 import (
+	"encoding/json"
+	"math"
+	"math/big"
 	"net/mail"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/evgeniron/API-Validator/model"
 )
 
 var Validators = map[string]func(interface{}) bool{
 	"Int":        IntValidator,
+	"Int32":      Int32Validator,
+	"Int64":      Int64Validator,
+	"Float":      FloatValidator,
+	"Decimal":    DecimalValidator,
 	"Date":       DateValidator,
+	"ISODate":    ISODateValidator,
 	"Boolean":    BooleanValidator,
 	"List":       JSONValidator,
+	"Object":     ObjectValidator,
 	"UUID":       UUIDValidator,
 	"BearerAuth": BearerAuthValidator,
 	"String":     StringValidator,
+	"Email":      EmailValidator,
+	"File":       FileValidator,
+}
+
+// KnownType reports whether fields of the named type can be validated
+func KnownType(name string) bool {
+	_, ok := Validators[name]
+	return ok
+}
+
+// MatchesType reports whether the value is valid for the built-in type. Values of
+// wire sections are strings on the wire, they are read as in wireValue.
+func MatchesType(name string, value interface{}, wire bool) bool {
+	validator, ok := Validators[name]
+	if !ok {
+		return false
+	}
+	if text, ok := value.(string); ok && wire {
+		value = wireValue(name, text)
+	}
+	return validator(value)
+}
+
+// wireValue reads a string of a wire section as a value of the built-in type,
+// numeric types read numbers and Boolean reads "true" and "false". Strings of
+// other types are left as they are.
+func wireValue(base, text string) interface{} {
+	switch {
+	case NumericTypes[base]:
+		return wireNumber(text)
+	case base == "Boolean":
+		switch strings.TrimSpace(text) {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+	}
+	return text
 }
 
 // EmailValidator validates an email address (RFC 5322)
@@ -28,9 +79,145 @@ func EmailValidator(value interface{}) bool {
 	return err == nil
 }
 
-// IntValidator validates an integer value
+// NumericTypes are the types that accept numeric strings in sections whose values
+// are always strings on the wire: path params, query params and headers
+var NumericTypes = map[string]bool{
+	"Int":     true,
+	"Int32":   true,
+	"Int64":   true,
+	"Float":   true,
+	"Decimal": true,
+}
+
+// numberPattern is the JSON number grammar (RFC 8259)
+var numberPattern = model.NumberPattern
+
+// maxIntegerExponent bounds the exponent of numbers checked for being integral, any
+// larger exponent overflows 64 bits and would make big.Rat allocate huge numbers
+const maxIntegerExponent = 40
+
+// numberLiteral returns the JSON number literal of a numeric value. JSON decoded
+// with UseNumber gives json.Number, while Go callers may pass any numeric type.
+func numberLiteral(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		literal := strings.TrimSpace(v.String())
+		return literal, numberPattern.MatchString(literal)
+	case int:
+		return strconv.FormatInt(int64(v), 10), true
+	case int8:
+		return strconv.FormatInt(int64(v), 10), true
+	case int16:
+		return strconv.FormatInt(int64(v), 10), true
+	case int32:
+		return strconv.FormatInt(int64(v), 10), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	}
+	return "", false
+}
+
+func formatFloat(value float64, bitSize int) (string, bool) {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return "", false
+	}
+	return strconv.FormatFloat(value, 'g', -1, bitSize), true
+}
+
+// integerValue returns the value as int64 when it is an integral number in the 64-bit range
+func integerValue(value interface{}) (int64, bool) {
+	literal, ok := numberLiteral(value)
+	if !ok {
+		return 0, false
+	}
+
+	if integer, err := strconv.ParseInt(literal, 10, 64); err == nil {
+		return integer, true
+	}
+
+	// integral values written with a fraction or an exponent, e.g. 1.0 or 1e3
+	if exponent := strings.IndexAny(literal, "eE"); exponent >= 0 {
+		e, err := strconv.Atoi(literal[exponent+1:])
+		if err != nil || e > maxIntegerExponent || e < -maxIntegerExponent {
+			return 0, false
+		}
+	}
+
+	rat, ok := new(big.Rat).SetString(literal)
+	if !ok || !rat.IsInt() || !rat.Num().IsInt64() {
+		return 0, false
+	}
+	return rat.Num().Int64(), true
+}
+
+// maxRatExponent bounds the exponent of numbers compared exactly, it exceeds the
+// float range so that every Float value can be compared
+const maxRatExponent = 400
+
+// ratValue returns the exact value of a number. Numbers with a larger exponent
+// than maxRatExponent are rejected, as big.Rat would allocate huge numbers for them.
+func ratValue(value interface{}) (*big.Rat, bool) {
+	literal, ok := numberLiteral(value)
+	if !ok {
+		return nil, false
+	}
+
+	if exponent := strings.IndexAny(literal, "eE"); exponent >= 0 {
+		e, err := strconv.Atoi(literal[exponent+1:])
+		if err != nil || e > maxRatExponent || e < -maxRatExponent {
+			return nil, false
+		}
+	}
+	return new(big.Rat).SetString(literal)
+}
+
+// IntValidator validates an integer value within the 64-bit range
 func IntValidator(value interface{}) bool {
-	_, ok := value.(int)
+	_, ok := integerValue(value)
+	return ok
+}
+
+// Int32Validator validates an integer value within the 32-bit range
+func Int32Validator(value interface{}) bool {
+	integer, ok := integerValue(value)
+	return ok && integer >= math.MinInt32 && integer <= math.MaxInt32
+}
+
+// Int64Validator validates an integer value within the 64-bit range
+func Int64Validator(value interface{}) bool {
+	_, ok := integerValue(value)
+	return ok
+}
+
+// FloatValidator validates a number representable as a finite 64-bit float
+func FloatValidator(value interface{}) bool {
+	literal, ok := numberLiteral(value)
+	if !ok {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(literal, 64)
+	return err == nil
+}
+
+// DecimalValidator validates a number of any magnitude and precision, as decimal
+// amounts are checked on their literal rather than on a lossy float
+func DecimalValidator(value interface{}) bool {
+	_, ok := numberLiteral(value)
 	return ok
 }
 
@@ -52,6 +239,43 @@ func JSONValidator(value interface{}) bool {
 	return ok
 }
 
+// ObjectValidator validates a JSON object
+func ObjectValidator(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
+
+// FileValidator validates a file part, an object as built by FileValue and
+// checked by fileValue
+func FileValidator(value interface{}) bool {
+	_, ok := fileValue(value)
+	return ok
+}
+
+type file struct {
+	contentType string
+	size        int64
+}
+
+func fileValue(value interface{}) (file, bool) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return file{}, false
+	}
+	if _, ok := object["filename"].(string); !ok {
+		return file{}, false
+	}
+	contentType, ok := object["content_type"].(string)
+	if !ok {
+		return file{}, false
+	}
+	size, ok := integerValue(object["size"])
+	if !ok || size < 0 {
+		return file{}, false
+	}
+	return file{contentType: contentType, size: size}, true
+}
+
 // DateValidator validates a date with format "dd-mm-yyyy"
 func DateValidator(value interface{}) bool {
 	date, ok := value.(string)
@@ -63,6 +287,17 @@ func DateValidator(value interface{}) bool {
 	return err == nil
 }
 
+// ISODateValidator validates a date with format "yyyy-mm-dd" (RFC 3339 full-date)
+func ISODateValidator(value interface{}) bool {
+	date, ok := value.(string)
+	if !ok {
+		return false
+	}
+
+	_, err := time.Parse("2006-01-02", date)
+	return err == nil
+}
+
 // UUIDValidator validates a UUID value
 func UUIDValidator(value interface{}) bool {
 	uuid, ok := value.(string)